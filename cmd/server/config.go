@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	kyaml "github.com/knadh/koanf/parsers/yaml"
+	"github.com/spf13/cobra"
+	"github.com/zacscoding/go-rest-template/internal/config"
+	"github.com/zacscoding/go-rest-template/pkg/cfgloader"
+	"github.com/zacscoding/go-rest-template/pkg/utils/maskingutil"
+)
+
+var (
+	printFormat     string
+	printShowSource bool
+)
+
+func init() {
+	configPrintCommand.Flags().StringVar(&printFormat, "format", "yaml", "output format. yaml or json")
+	configPrintCommand.Flags().BoolVar(&printShowSource, "show-source", false, "show the source of each key. default, env, file or override")
+	configCommand.AddCommand(configPrintCommand, configValidateCommand, configDocsCommand)
+	rootCmd.AddCommand(configCommand)
+}
+
+var configCommand = &cobra.Command{
+	Use:   "config",
+	Short: "Print, validate and document configs",
+}
+
+var configPrintCommand = &cobra.Command{
+	Use:   "print",
+	Short: "Print effective configs merged from defaults, env and config files with secrets masked",
+	Run:   runConfigPrint,
+}
+
+var configValidateCommand = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate configs and exit with non-zero status if invalid",
+	Run:   runConfigValidate,
+}
+
+var configDocsCommand = &cobra.Command{
+	Use:   "docs",
+	Short: "Print a markdown reference of all config keys",
+	Run:   runConfigDocs,
+}
+
+func runConfigPrint(*cobra.Command, []string) {
+	if err := printConfigs(os.Stdout, configFiles(), printFormat, printShowSource); err != nil {
+		exitf("%v", err)
+	}
+}
+
+// printConfigs writes effective configs loaded from given files in given format.
+// Each key is printed with the source which set it last if showSource is true.
+func printConfigs(w io.Writer, files config.Files, format string, showSource bool) error {
+	if format != "yaml" && format != "json" {
+		return fmt.Errorf("not supported format: %s", format)
+	}
+	conf, err := config.Load(files, nil)
+	if err != nil {
+		return fmt.Errorf("invalid configs: %v", err)
+	}
+	// MarshalJSON flattens keys except lists and masks secrets.
+	b, err := json.Marshal(conf)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configs: %v", err)
+	}
+	var m map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&m); err != nil {
+		return fmt.Errorf("failed to unmarshal configs: %v", err)
+	}
+	if err := formatDurations(m); err != nil {
+		return fmt.Errorf("failed to describe configs: %v", err)
+	}
+	for key, value := range m {
+		m[key] = typedNumbers(value)
+	}
+	if showSource {
+		origins, err := config.Origins(files, nil)
+		if err != nil {
+			return fmt.Errorf("failed to load sources of configs: %v", err)
+		}
+		for key, value := range m {
+			source, ok := origins[key]
+			if !ok {
+				source = config.SourceDefault
+			}
+			m[key] = map[string]interface{}{"value": value, "source": source}
+		}
+	}
+	if err := printConfig(w, format, m); err != nil {
+		return fmt.Errorf("failed to print configs: %v", err)
+	}
+	return nil
+}
+
+// formatDurations formats nanoseconds of duration keys in given m like "1m30s".
+func formatDurations(m map[string]interface{}) error {
+	docs, err := config.Describe()
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if doc.Type != "time.Duration" {
+			continue
+		}
+		if n, ok := m[doc.Key].(json.Number); ok {
+			if v, err := n.Int64(); err == nil {
+				m[doc.Key] = time.Duration(v).String()
+			}
+		}
+	}
+	return nil
+}
+
+// typedNumbers converts json.Number in given v to int64 or float64, so they are not printed as strings in yaml.
+func typedNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+	case []interface{}:
+		for i := range value {
+			value[i] = typedNumbers(value[i])
+		}
+	case map[string]interface{}:
+		for key := range value {
+			value[key] = typedNumbers(value[key])
+		}
+	}
+	return v
+}
+
+func printConfig(w io.Writer, format string, m map[string]interface{}) error {
+	var (
+		b   []byte
+		err error
+	)
+	if format == "json" {
+		b, err = json.MarshalIndent(m, "", "    ")
+		b = append(b, '\n')
+	} else {
+		b, err = kyaml.Parser().Marshal(m)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func runConfigValidate(*cobra.Command, []string) {
+	if _, err := config.Load(configFiles(), nil); err != nil {
+		exitf("invalid configs: %v", err)
+	}
+	fmt.Println("configs are valid")
+}
+
+func runConfigDocs(*cobra.Command, []string) {
+	docs, err := config.Describe()
+	if err != nil {
+		exitf("failed to describe configs: %v", err)
+	}
+	fmt.Println("| Key | Type | Default | Env |")
+	fmt.Println("|-----|------|---------|-----|")
+	for _, doc := range docs {
+		fmt.Printf("| `%s` | `%s` | %s | `%s` |\n", doc.Key, doc.Type, formatDefault(doc), doc.Env)
+	}
+	fmt.Println()
+	fmt.Printf("Env variables with `_FILE` suffix e.g. `%sSERVER_AUTH_JWT_KEY_FILE` refer to files containing values.\n", config.EnvPrefix)
+}
+
+// formatDefault formats a default value of given doc with secrets masked.
+func formatDefault(doc cfgloader.KeyDoc) string {
+	if doc.Default == nil {
+		return ""
+	}
+	if m, ok := doc.Default.(map[string]interface{}); ok {
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]string, 0, len(keys))
+		for _, key := range keys {
+			value := fmt.Sprint(m[key])
+			if doc.Secret {
+				value = maskingutil.Mask
+			}
+			values = append(values, fmt.Sprintf("`%s=%s`", key, value))
+		}
+		return strings.Join(values, ", ")
+	}
+	if doc.Secret && !reflect.ValueOf(doc.Default).IsZero() {
+		return "`" + maskingutil.Mask + "`"
+	}
+	return fmt.Sprintf("`%v`", doc.Default)
+}
+
+func exitf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zacscoding/go-rest-template/internal/config"
+)
+
+func TestPrintConfigs(t *testing.T) {
+	files := config.Files{Dir: "../../config"}
+
+	t.Run("Yaml", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		assert.NoError(t, printConfigs(&buf, files, "yaml", false))
+
+		// numbers are not quoted and lists are not flattened.
+		assert.Contains(t, buf.String(), "\ndb.batch-size: 100\n")
+		assert.Contains(t, buf.String(), "\ncache.redis.endpoints:\n    - localhost:6379\n")
+		assert.Contains(t, buf.String(), "\nserver.read-timeout: 5s\n")
+		assert.Contains(t, buf.String(), "\ndb.data-source-name: root:****@")
+		assert.NotContains(t, buf.String(), "endpoints.0")
+	})
+
+	t.Run("Show Source", func(t *testing.T) {
+		t.Setenv(config.EnvPrefix+"LOGGING_ERROR-OUTPUT", "stdout,stderr")
+		var buf bytes.Buffer
+
+		assert.NoError(t, printConfigs(&buf, files, "json", true))
+
+		var m map[string]struct {
+			Value  interface{} `json:"value"`
+			Source string      `json:"source"`
+		}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+		assert.Equal(t, "file:../../config/base.yml", m["cache.redis.endpoints"].Source)
+		assert.Equal(t, []interface{}{"localhost:6379"}, m["cache.redis.endpoints"].Value)
+		assert.Equal(t, config.SourceEnv, m["logging.error-output"].Source)
+		assert.Equal(t, []interface{}{"stdout", "stderr"}, m["logging.error-output"].Value)
+		assert.Equal(t, "file:../../config/base.yml", m["db.batch-size"].Source)
+		assert.Equal(t, float64(100), m["db.batch-size"].Value)
+		assert.Equal(t, config.SourceDefault, m["metric.namespace"].Source)
+	})
+
+	t.Run("Unknown Format", func(t *testing.T) {
+		assert.ErrorContains(t, printConfigs(&bytes.Buffer{}, files, "xml", false), "not supported format: xml")
+	})
+}
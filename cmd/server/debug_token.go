@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zacscoding/go-rest-template/internal/config"
+)
+
+var (
+	debugTokenRequestID string
+	debugTokenTTL       time.Duration
+)
+
+func init() {
+	debugTokenCommand.Flags().StringVar(&debugTokenRequestID, "request-id", "", "X-Request-ID header of requests sent with the token")
+	debugTokenCommand.Flags().DurationVar(&debugTokenTTL, "ttl", 10*time.Minute, "lifetime of the token. must not exceed logging.debug.max-ttl")
+	_ = debugTokenCommand.MarkFlagRequired("request-id")
+	rootCmd.AddCommand(debugTokenCommand)
+}
+
+var debugTokenCommand = &cobra.Command{
+	Use:   "debug-token",
+	Short: "Print a token of the X-Debug-Log header enabling debug logs of requests",
+	Run:   runDebugToken,
+}
+
+func runDebugToken(*cobra.Command, []string) {
+	conf, err := config.Load(configFiles(), nil)
+	if err != nil {
+		exitf("invalid configs: %v", err)
+	}
+	signer := conf.Logging.DebugSigner()
+	if signer == nil {
+		exitf("require logging.debug.secret")
+	}
+	token, err := signer.Sign(debugTokenRequestID, debugTokenTTL)
+	if err != nil {
+		exitf("failed to sign a token: %v", err)
+	}
+	fmt.Println(token)
+}
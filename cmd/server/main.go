@@ -4,9 +4,13 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/zacscoding/go-rest-template/internal/config"
 )
 
-var configPath string
+var (
+	configDir   string
+	configPaths []string
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "server",
@@ -15,7 +19,13 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "f", "", "config file")
+	rootCmd.PersistentFlags().StringVar(&configDir, "config-dir", "config", "config directory having base.yml, <stage>.yml and conf.d/*.yml")
+	rootCmd.PersistentFlags().StringSliceVarP(&configPaths, "config", "f", nil, "config files or directories merged in order")
+}
+
+// configFiles returns config files of the config flags.
+func configFiles() config.Files {
+	return config.Files{Dir: configDir, Paths: configPaths}
 }
 
 func main() {
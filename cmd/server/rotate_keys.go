@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zacscoding/go-rest-template/internal/config"
+	"github.com/zacscoding/go-rest-template/internal/model"
+	"github.com/zacscoding/go-rest-template/pkg/cache"
+	"github.com/zacscoding/go-rest-template/pkg/database"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	rootCmd.AddCommand(rotateKeysCommand)
+}
+
+var rotateKeysCommand = &cobra.Command{
+	Use:   "rotate-keys",
+	Short: "Re-encrypt encrypted columns with the active encryption key",
+	Run:   runRotateKeys,
+}
+
+// rotateKeysLockTTL is a lease of the lock preventing concurrent rotations, extended while rotating.
+const rotateKeysLockTTL = 30 * time.Second
+
+// encryptedModels are models having encrypted fields.
+var encryptedModels = []schema.Tabler{
+	&model.User{},
+}
+
+func runRotateKeys(*cobra.Command, []string) {
+	conf := loadConfig()
+	logger, levels := setupLogger(conf)
+	defer logger.Sync()
+
+	// exits after rotateKeys returns, so the lock is released by deferred calls.
+	if err := rotateKeys(conf, logger, levels); err != nil {
+		logger.Fatalw("failed to rotate keys", "err", err)
+	}
+}
+
+// rotateKeys re-encrypts encryptedModels with the active key while holding the lock of rotations.
+func rotateKeys(conf *config.Config, logger *zap.SugaredLogger, levels *logging.Levels) error {
+	// rotations on other nodes are excluded by the lock on redis.
+	cacher, err := cache.NewCacher(&conf.Cache, nil, logger)
+	if err != nil {
+		return fmt.Errorf("create a cacher: %w", err)
+	}
+	if cacher != nil {
+		defer cacher.Close()
+	}
+	locker, err := cache.NewLocker(&conf.Cache, cacher)
+	if err != nil {
+		return fmt.Errorf("create a locker: %w", err)
+	}
+	defer locker.Close()
+	lock, err := locker.TryLock(context.Background(), "rotate-keys", rotateKeysLockTTL)
+	if err != nil {
+		return fmt.Errorf("acquire a lock. other rotation may be running: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go keepLock(ctx, cancel, lock, rotateKeysLockTTL, logger)
+	defer lock.Release(context.Background())
+
+	enc, err := database.NewEncryptor(&conf.DB)
+	if err != nil {
+		return fmt.Errorf("create an encryptor: %w", err)
+	}
+	db, _, err := database.Open(&conf.DB, enc, logger, levels)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	for _, m := range encryptedModels {
+		rows, err := database.ReEncrypt(ctx, db, m, conf.DB.BatchSize)
+		if err != nil {
+			return fmt.Errorf("re-encrypt rows of %s after %d rows: %w", m.TableName(), rows, err)
+		}
+		logger.Infow("re-encrypted rows", "table", m.TableName(), "rows", rows, "keyId", conf.DB.Encryption.ActiveKeyID)
+	}
+	return nil
+}
+
+// keepLock extends given lock until the ctx is done. Calls cancel if the lock is lost.
+func keepLock(ctx context.Context, cancel context.CancelFunc, lock cache.Lock, ttl time.Duration, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := lock.Extend(ctx, ttl); err != nil {
+				logger.Errorw("failed to extend a lock", "key", lock.Key(), "err", err)
+				cancel()
+				return
+			}
+		}
+	}
+}
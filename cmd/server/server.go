@@ -12,33 +12,18 @@ import (
 	"github.com/zacscoding/go-rest-template/internal/metrics"
 	"github.com/zacscoding/go-rest-template/internal/server"
 	"github.com/zacscoding/go-rest-template/internal/store"
+	"github.com/zacscoding/go-rest-template/migrations"
 	"github.com/zacscoding/go-rest-template/pkg/cache"
 	"github.com/zacscoding/go-rest-template/pkg/database"
 	"github.com/zacscoding/go-rest-template/pkg/logging"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
 )
 
 func runApplication(*cobra.Command, []string) {
-	conf, err := config.Load(configPath, nil)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// setup logging
-	logging.SetConfig(&logging.Config{
-		Encoding:          conf.Logging.Encoding,
-		Level:             zapcore.Level(conf.Logging.Level),
-		Development:       false,
-		EncoderConfig:     logging.NewEncoderConfig(),
-		DisableStacktrace: conf.Logging.DisableStacktrace,
-	})
-	defer logging.DefaultLogger().Sync()
-
-	b, _ := json.MarshalIndent(conf, "", "    ")
-	logging.DefaultLogger().Infof("Starting applicaltion server. configs: %s", string(b))
+	conf := loadConfig()
 
 	// setup global components at here
 	if tr, ok := http.DefaultTransport.(*http.Transport); ok {
@@ -48,12 +33,34 @@ func runApplication(*cobra.Command, []string) {
 	runApplicationReal(conf)
 }
 
+// loadConfig loads configs from the config flags.
+func loadConfig() *config.Config {
+	conf, err := config.Load(configFiles(), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// use embedded migrations unless "db.migrate.dir" is configured.
+	conf.DB.Migrate.FS = migrations.FS
+	conf.DB.Migrate.Backfills = migrations.Backfills
+	return conf
+}
+
+// setupLogger builds a logger from given conf for commands without fx.
+func setupLogger(conf *config.Config) (*zap.SugaredLogger, *logging.Levels) {
+	logger, levels, err := logging.NewLogger(conf.Logging.LoggerConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+	return logger.Sugar(), levels
+}
+
 func runApplicationReal(conf *config.Config) {
 	fx.New(
 		fx.Supply(conf),
 		fx.Supply(&conf.DB),
 		fx.Supply(&conf.Cache),
-		fx.Supply(logging.DefaultLogger().Desugar()),
+		fx.Supply(conf.Logging.LoggerConfig()),
+		logging.Module,
 		fx.WithLogger(func(log *zap.Logger) fxevent.Logger {
 			return &fxevent.ZapLogger{Logger: log.Named("fx")}
 		}),
@@ -62,10 +69,21 @@ func runApplicationReal(conf *config.Config) {
 		fx.Provide(
 			// setup metrics provider
 			metrics.NewProvider,
+			func(mp metrics.Provider) cache.Recorder {
+				return mp
+			},
 
 			// setup database and stores
+			database.NewEncryptor,
 			database.Open,
-			cache.NewCacher,
+			func(lc fx.Lifecycle, conf *cache.Config, recorder cache.Recorder, logger *zap.SugaredLogger) (cache.Cacher, error) {
+				cacher, err := cache.NewCacher(conf, recorder, logger)
+				if err != nil || cacher == nil {
+					return cacher, err
+				}
+				lc.Append(fx.StopHook(cacher.Close))
+				return cacher, nil
+			},
 			store.NewUserStore,
 
 			// setup controllers
@@ -73,8 +91,31 @@ func runApplicationReal(conf *config.Config) {
 			controller.NewUserController,
 
 			server.NewServer,
+			config.NewReloader,
 		),
 		fx.Invoke(
+			func(logger *zap.SugaredLogger) {
+				b, _ := json.MarshalIndent(conf, "", "    ")
+				logger.Infof("Starting applicaltion server. configs: %s", string(b))
+			},
+			func(cacher cache.Cacher, mp metrics.Provider) error {
+				if reporter, ok := cacher.(cache.StatsReporter); ok {
+					if err := mp.RegisterCacheStats(reporter); err != nil {
+						return err
+					}
+				}
+				if reporter, ok := cacher.(cache.CircuitReporter); ok {
+					return mp.RegisterCacheCircuit(reporter)
+				}
+				return nil
+			},
+			// apply rotated secrets on SIGHUP
+			func(reloader *config.Reloader, db *gorm.DB, authController *controller.AuthController) {
+				reloader.OnReload(func(conf *config.Config) error {
+					return database.Reload(db, &conf.DB)
+				})
+				reloader.OnReload(authController.Reload)
+			},
 			func(srv *server.Server) error {
 				return srv.RouteAPI()
 			}),
@@ -1,15 +1,20 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
-	"github.com/jeremywohl/flatten"
+	"github.com/hashicorp/go-multierror"
 	"github.com/knadh/koanf"
 	"github.com/zacscoding/go-rest-template/pkg/cache"
 	"github.com/zacscoding/go-rest-template/pkg/cfgloader"
 	"github.com/zacscoding/go-rest-template/pkg/database"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
 	"github.com/zacscoding/go-rest-template/pkg/utils/maskingutil"
+	"go.uber.org/zap/zapcore"
 )
 
 const EnvPrefix = "APP_SERVER_"
@@ -29,6 +34,39 @@ type LoggingConfig struct {
 	Encoding          string `json:"encoding" yaml:"encoding"`
 	Development       bool   `json:"development" yaml:"development"`
 	DisableStacktrace bool   `json:"disable-stacktrace" yaml:"disable-stacktrace"`
+	// Sinks are outputs of logs. Writes to stdout if empty.
+	Sinks    []logging.SinkConfig   `json:"sinks" yaml:"sinks"`
+	Sampling logging.SamplingConfig `json:"sampling" yaml:"sampling"`
+	// ErrorOutput are outputs of internal errors of loggers.
+	ErrorOutput []string `json:"error-output" yaml:"error-output"`
+	// Debug enables debug logs of a request having a X-Debug-Log header signed for its X-Request-ID with Secret.
+	// Disabled if empty.
+	Debug struct {
+		Secret string        `json:"secret" yaml:"secret" secret:"true"`
+		MaxTTL time.Duration `json:"max-ttl" yaml:"max-ttl"`
+	} `json:"debug" yaml:"debug"`
+}
+
+// LoggerConfig returns a config to build loggers by logging.NewLogger.
+func (c *LoggingConfig) LoggerConfig() *logging.Config {
+	return &logging.Config{
+		Encoding:          c.Encoding,
+		Level:             zapcore.Level(c.Level),
+		Development:       c.Development,
+		EncoderConfig:     logging.NewEncoderConfig(),
+		DisableStacktrace: c.DisableStacktrace,
+		Sinks:             c.Sinks,
+		Sampling:          c.Sampling,
+		ErrorOutputPaths:  c.ErrorOutput,
+	}
+}
+
+// DebugSigner returns a signer of debug log tokens or nil if logging.debug.secret is empty.
+func (c *LoggingConfig) DebugSigner() *logging.DebugSigner {
+	if c.Debug.Secret == "" {
+		return nil
+	}
+	return logging.NewDebugSigner(c.Debug.Secret, c.Debug.MaxTTL)
 }
 
 type ServerConfig struct {
@@ -48,7 +86,7 @@ type ServerConfig struct {
 	Auth struct {
 		JWT struct {
 			Realm      string        `json:"realm" yaml:"realm"`
-			Key        string        `json:"key" yaml:"key"`
+			Key        string        `json:"key" yaml:"key" secret:"true"`
 			Timeout    time.Duration `json:"timeout" yaml:"timeout"`
 			MaxRefresh time.Duration `json:"max-refresh" yaml:"max-refresh"`
 		} `json:"jwt" yaml:"jwt"`
@@ -62,19 +100,29 @@ type MetricsConfig struct {
 	Subsystem string `json:"subsystem" yaml:"subsystem"`
 }
 
+// Names of config sources. Files are named by SourceFile with paths, e.g. "file:config/base.yml".
+const (
+	SourceDefault  = cfgloader.SourceDefault
+	SourceEnv      = "env"
+	SourceFile     = "file"
+	SourceOverride = "override"
+)
+
 // Load loads config with below orders.
 // 1. defaultConfig constants.
-// 2. environment variables having "APP_SERVER_" prefix.
-// 3. config file if provided
+// 2. config files if provided. See Files for orders of them.
+// 3. environment variables having "APP_SERVER_" prefix.
 // 4. configMap if not empty.
-func Load(configPath string, configMap map[string]interface{}) (*Config, error) {
-	var opts []cfgloader.Option
-	opts = append(opts, cfgloader.WithEnv(EnvPrefix))
-	if configPath != "" {
-		opts = append(opts, cfgloader.WithConfigFile(configPath))
+//
+// Unknown keys of config files and configMap are invalid, while unknown env variables are ignored.
+func Load(files Files, configMap map[string]interface{}) (*Config, error) {
+	srcs, err := sources(files, configMap)
+	if err != nil {
+		return nil, err
 	}
-	if len(configMap) != 0 {
-		opts = append(opts, cfgloader.WithConfigMap(configMap))
+	var opts []cfgloader.Option
+	for _, s := range srcs {
+		opts = append(opts, s.Option)
 	}
 
 	var conf Config
@@ -83,41 +131,169 @@ func Load(configPath string, configMap map[string]interface{}) (*Config, error)
 		return nil, err
 	}
 	conf.K = k
+
+	var result *multierror.Error
+	unknown, err := unknownKeys(&conf, srcs)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range unknown {
+		result = multierror.Append(result, fmt.Errorf("unknown key: %s", key))
+	}
+	result = multierror.Append(result, conf.Validate())
+	if err := result.ErrorOrNil(); err != nil {
+		return nil, err
+	}
 	return &conf, nil
 }
 
-func (c *Config) MarshalJSON() ([]byte, error) {
-	type conf Config
-	alias := conf(*c)
+// unknownKeys returns keys of given sources not matched with fields of given conf.
+// Env is not checked since it is shared with other programs,
+// e.g. "APP_SERVER_SERVICE_HOST" is set by Kubernetes for a service named "app-server".
+func unknownKeys(conf *Config, srcs []cfgloader.Source) ([]string, error) {
+	k := koanf.New(".")
+	for _, s := range srcs {
+		if s.Name == SourceEnv {
+			continue
+		}
+		if err := s.Option(k); err != nil {
+			return nil, err
+		}
+	}
+	return cfgloader.UnknownKeys(k, conf), nil
+}
 
-	data, err := json.Marshal(&alias)
+// Origins returns the source name of each key loaded by Load with the same arguments.
+// Keys not in the result are zero values.
+func Origins(files Files, configMap map[string]interface{}) (map[string]string, error) {
+	srcs, err := sources(files, configMap)
 	if err != nil {
 		return nil, err
 	}
+	return cfgloader.Origins(defaultConfig, srcs...)
+}
+
+// Describe returns docs of all config keys with defaults and env variable names.
+func Describe() ([]cfgloader.KeyDoc, error) {
+	return cfgloader.Describe(&Config{}, defaultConfig, EnvPrefix)
+}
 
-	flat, err := flatten.FlattenString(string(data), "", flatten.DotStyle)
+func sources(files Files, configMap map[string]interface{}) ([]cfgloader.Source, error) {
+	paths, err := files.list(configMap)
 	if err != nil {
 		return nil, err
 	}
+	var sources []cfgloader.Source
+	for _, path := range paths {
+		sources = append(sources, cfgloader.Source{Name: SourceFile + ":" + path, Option: cfgloader.WithConfigFile(path)})
+	}
+	sources = append(sources, cfgloader.Source{Name: SourceEnv, Option: cfgloader.WithEnv(EnvPrefix)})
+	if len(configMap) != 0 {
+		sources = append(sources, cfgloader.Source{Name: SourceOverride, Option: cfgloader.WithConfigMap(configMap)})
+	}
+	return sources, nil
+}
+
+// Validate returns all errors of invalid configs together.
+func (c *Config) Validate() error {
+	var result *multierror.Error
+	if c.Logging.Level < int(zapcore.DebugLevel) || c.Logging.Level > int(zapcore.FatalLevel) {
+		result = multierror.Append(result, fmt.Errorf("logging.level must be in [-1, 5]: %d", c.Logging.Level))
+	}
+	if c.Logging.Encoding != "json" && c.Logging.Encoding != "console" {
+		result = multierror.Append(result, fmt.Errorf("logging.encoding must be json or console: %q", c.Logging.Encoding))
+	}
+	for i := range c.Logging.Sinks {
+		if err := c.Logging.Sinks[i].Validate(); err != nil {
+			result = multierror.Append(result, multierror.Prefix(err, fmt.Sprintf("logging.sinks[%d]:", i)))
+		}
+	}
+	result = multierror.Append(result, multierror.Prefix(c.Logging.Sampling.Validate(), "logging:"))
+	if c.Logging.Debug.Secret != "" && c.Logging.Debug.MaxTTL <= 0 {
+		result = multierror.Append(result, errors.New("logging.debug.max-ttl must be positive"))
+	}
+	result = multierror.Append(result, c.Server.validate())
+	if c.Metric.Enabled {
+		if !validPort(c.Metric.Port) {
+			result = multierror.Append(result, fmt.Errorf("invalid metric.port: %d", c.Metric.Port))
+		}
+		if c.Metric.Namespace == "" {
+			result = multierror.Append(result, errors.New("require metric.namespace"))
+		}
+	}
+	result = multierror.Append(result, multierror.Prefix(c.DB.Validate(), "db:"))
+	result = multierror.Append(result, multierror.Prefix(c.Cache.Validate(), "cache:"))
+	return result.ErrorOrNil()
+}
+
+func (c *ServerConfig) validate() error {
+	var result *multierror.Error
+	if !validPort(c.Port) {
+		result = multierror.Append(result, fmt.Errorf("invalid server.port: %d", c.Port))
+	}
+	if c.ReadTimeout <= 0 || c.WriteTimeout <= 0 {
+		result = multierror.Append(result, errors.New("server.read-timeout and server.write-timeout must be positive"))
+	}
+	if c.GracefulShutdown < 0 {
+		result = multierror.Append(result, fmt.Errorf("server.graceful-shutdown must not be negative: %s", c.GracefulShutdown))
+	}
+	if !c.Cors.AllowAll && len(c.Cors.Origin) == 0 {
+		result = multierror.Append(result, errors.New("require server.cors.origin if server.cors.allow-all is false"))
+	}
+	if c.Docs.Enabled && c.Docs.Path == "" {
+		result = multierror.Append(result, errors.New("require server.docs.path if server.docs.enabled"))
+	}
+	if c.Auth.JWT.Key == "" {
+		result = multierror.Append(result, errors.New("require server.auth.jwt.key"))
+	}
+	if c.Auth.JWT.Timeout <= 0 || c.Auth.JWT.MaxRefresh < c.Auth.JWT.Timeout {
+		result = multierror.Append(result, errors.New("server.auth.jwt.timeout must be positive and not exceed server.auth.jwt.max-refresh"))
+	}
+	return result.ErrorOrNil()
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// MarshalJSON encodes configs flattened by keys with secrets masked. Lists are not flattened like keys of koanf.
+// Fields having `secret:"true"` tag and values resolved from secret references are masked.
+func (c *Config) MarshalJSON() ([]byte, error) {
+	type conf Config
+	alias := conf(*c)
 
-	var m map[string]interface{}
-	err = json.Unmarshal([]byte(flat), &m)
+	data, err := json.Marshal(maskingutil.MaskValue(&alias))
 	if err != nil {
 		return nil, err
 	}
 
-	// add keys if u want to mask some properties.
-	maskKeys := map[string]struct{}{
-		"server.auth.jwt.key": {},
+	var nested map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	// keeps numbers as they are, e.g. nanoseconds of durations.
+	d.UseNumber()
+	if err := d.Decode(&nested); err != nil {
+		return nil, err
 	}
+	m := make(map[string]interface{})
+	flattenMap("", nested, m)
 
-	for key, val := range m {
-		if v, ok := val.(string); ok {
-			m[key] = maskingutil.MaskPassword(v)
-		}
-		if _, ok := maskKeys[key]; ok {
-			m[key] = "****"
+	if c.K != nil {
+		for key := range m {
+			if cfgloader.IsSecretRef(c.K.String(key)) {
+				m[key] = maskingutil.Mask
+			}
 		}
 	}
 	return json.Marshal(&m)
 }
+
+// flattenMap sets values of given nested maps to given flat map with keys joined by ".".
+func flattenMap(prefix string, nested, flat map[string]interface{}) {
+	for key, value := range nested {
+		if m, ok := value.(map[string]interface{}); ok && len(m) != 0 {
+			flattenMap(prefix+key+".", m, flat)
+			continue
+		}
+		flat[prefix+key] = value
+	}
+}
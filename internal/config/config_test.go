@@ -2,14 +2,28 @@ package config
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zacscoding/go-rest-template/pkg/cfgloader"
 )
 
+// testEncryption is encryption configs required to load configs without files.
+var testEncryption = map[string]interface{}{
+	"db.encryption.active-key-id":   "test",
+	"db.encryption.keys.test":       "dGVzdC1hcHAtZW5jcnlwdGlvbi1rZXktMzJieXRlcyE=", // echo -n 'test-app-encryption-key-32bytes!' | base64
+	"db.encryption.blind-index-key": "dGVzdC1hcHAtYmxpbmQtaW5kZXgta2V5",             // echo -n 'test-app-blind-index-key' | base64
+}
+
 func TestLoad_Default(t *testing.T) {
-	conf, err := Load("", nil)
+	// unknown env variables are ignored, e.g. set by Kubernetes for a service named "app-server".
+	t.Setenv(EnvPrefix+"SERVICE_HOST", "10.0.0.1")
+
+	conf, err := Load(Files{}, testEncryption)
 	assert.NoError(t, err)
 
 	cases := []struct {
@@ -22,6 +36,13 @@ func TestLoad_Default(t *testing.T) {
 		{key: "logging.encoding", expected: "console", values: []interface{}{conf.Logging.Encoding}},
 		{key: "logging.development", expected: false, values: []interface{}{conf.Logging.Development}},
 		{key: "logging.disable-stacktrace", expected: true, values: []interface{}{conf.Logging.DisableStacktrace}},
+		{key: "logging.sampling.enabled", expected: false, values: []interface{}{conf.Logging.Sampling.Enabled}},
+		{key: "logging.sampling.tick", expected: time.Second, values: []interface{}{conf.Logging.Sampling.Tick}},
+		{key: "logging.sampling.initial", expected: 100, values: []interface{}{conf.Logging.Sampling.Initial}},
+		{key: "logging.sampling.thereafter", expected: 100, values: []interface{}{conf.Logging.Sampling.Thereafter}},
+		{key: "logging.error-output", expected: []string{"stderr"}, values: []interface{}{conf.Logging.ErrorOutput}},
+		{key: "logging.debug.secret", expected: "", values: []interface{}{conf.Logging.Debug.Secret}},
+		{key: "logging.debug.max-ttl", expected: time.Hour, values: []interface{}{conf.Logging.Debug.MaxTTL}},
 
 		{key: "server.port", expected: 8080, values: []interface{}{conf.Server.Port}},
 		{key: "server.read-timeout", expected: 5 * time.Second, values: []interface{}{conf.Server.ReadTimeout}},
@@ -48,7 +69,12 @@ func TestLoad_Default(t *testing.T) {
 		{key: "cache.enabled", expected: false, values: []interface{}{conf.Cache.Enabled}},
 		{key: "cache.prefix", expected: "myapp-", values: []interface{}{conf.Cache.Prefix}},
 		{key: "cache.type", expected: "redis", values: []interface{}{conf.Cache.Type}},
+		{key: "cache.codec", expected: "msgpack", values: []interface{}{conf.Cache.Codec}},
 		{key: "cache.ttl", expected: 1 * time.Minute, values: []interface{}{conf.Cache.TTL}},
+		{key: "cache.ttl-jitter", expected: 0.1, values: []interface{}{conf.Cache.TTLJitter}},
+		{key: "cache.negative-ttl", expected: 5 * time.Second, values: []interface{}{conf.Cache.NegativeTTL}},
+		{key: "cache.lock-ttl", expected: 3 * time.Second, values: []interface{}{conf.Cache.LockTTL}},
+		{key: "cache.load-timeout", expected: 5 * time.Second, values: []interface{}{conf.Cache.LoadTimeout}},
 		{key: "cache.redis.read-timeout", expected: 3 * time.Second, values: []interface{}{conf.Cache.Redis.ReadTimeout}},
 		{key: "cache.redis.write-timeout", expected: 3 * time.Second, values: []interface{}{conf.Cache.Redis.WriteTimeout}},
 		{key: "cache.redis.dial-timeout", expected: 5 * time.Second, values: []interface{}{conf.Cache.Redis.DialTimeout}},
@@ -56,6 +82,18 @@ func TestLoad_Default(t *testing.T) {
 		{key: "cache.redis.pool-timeout", expected: 4 * time.Second, values: []interface{}{conf.Cache.Redis.PoolTimeout}},
 		{key: "cache.redis.max-conn-age", expected: 0, values: []interface{}{conf.Cache.Redis.MaxConnAge}},
 		{key: "cache.redis.idle-timeout", expected: 60 * time.Second, values: []interface{}{conf.Cache.Redis.IdleTimeout}},
+		{key: "cache.redis.db", expected: 0, values: []interface{}{conf.Cache.Redis.DB}},
+		{key: "cache.redis.tls.enabled", expected: false, values: []interface{}{conf.Cache.Redis.TLS.Enabled}},
+		{key: "cache.memory.size", expected: 10000, values: []interface{}{conf.Cache.Memory.Size}},
+		{key: "cache.local.enabled", expected: false, values: []interface{}{conf.Cache.Local.Enabled}},
+		{key: "cache.local.size", expected: 10000, values: []interface{}{conf.Cache.Local.Size}},
+		{key: "cache.local.ttl", expected: 10 * time.Second, values: []interface{}{conf.Cache.Local.TTL}},
+		{key: "cache.local.channel", expected: "cache-invalidation", values: []interface{}{conf.Cache.Local.Channel}},
+		{key: "cache.breaker.enabled", expected: true, values: []interface{}{conf.Cache.Breaker.Enabled}},
+		{key: "cache.breaker.failure-threshold", expected: 5, values: []interface{}{conf.Cache.Breaker.FailureThreshold}},
+		{key: "cache.breaker.open-timeout", expected: 10 * time.Second, values: []interface{}{conf.Cache.Breaker.OpenTimeout}},
+		{key: "cache.breaker.half-open-requests", expected: 3, values: []interface{}{conf.Cache.Breaker.HalfOpenRequests}},
+		{key: "cache.locker.retry-interval", expected: 100 * time.Millisecond, values: []interface{}{conf.Cache.Locker.RetryInterval}},
 
 		{key: "metric.enabled", expected: true, values: []interface{}{conf.Metric.Enabled}},
 		{key: "metric.port", expected: 8089, values: []interface{}{conf.Metric.Port}},
@@ -76,9 +114,174 @@ func TestLoad_Default(t *testing.T) {
 	assert.Equal(t, len(defaultConfig), len(cases))
 }
 
+func TestLoad_Invalid(t *testing.T) {
+	_, err := Load(Files{}, map[string]interface{}{
+		"logging.encode":        "json",
+		"logging.encoding":      "text",
+		"logging.sinks":         []map[string]interface{}{{"path": "stdout", "rotation": map[string]interface{}{"enabled": true}}},
+		"logging.sampling":      map[string]interface{}{"enabled": true, "tick": "0s"},
+		"logging.debug":         map[string]interface{}{"secret": "debug-secret", "max-ttl": "0s"},
+		"server.port":           0,
+		"db.data-source-name":   "",
+		"cache.enabled":         true,
+		"cache.redis.endpoints": []string{},
+	})
+
+	assert.Error(t, err)
+	for _, msg := range []string{
+		"unknown key: logging.encode",
+		"logging.encoding must be json or console",
+		"logging.sinks[0]: rotation is not supported on stdout",
+		"logging: sampling tick and initial must be positive",
+		"logging.debug.max-ttl must be positive",
+		"invalid server.port: 0",
+		"db: require data-source-name",
+		"db: encryption: require active-key-id and keys",
+		"cache: redis: require at least one endpoint",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+}
+
+func TestLoad_ConfigFile(t *testing.T) {
+	conf, err := Load(Files{Dir: "../../config"}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "local", conf.Stage)
+	assert.Equal(t, "console", conf.Logging.Encoding)
+	assert.Equal(t, 100, conf.DB.BatchSize)
+
+	t.Run("Stage", func(t *testing.T) {
+		t.Setenv(EnvPrefix+"STAGE", "prod")
+
+		_, err := Load(Files{Dir: "../../config"}, nil)
+		assert.ErrorContains(t, err, "db: encryption: require active-key-id and keys")
+
+		conf, err := Load(Files{Dir: "../../config"}, testEncryption)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "prod", conf.Stage)
+		assert.Equal(t, "json", conf.Logging.Encoding)
+		assert.Equal(t, 100, conf.DB.BatchSize)
+	})
+}
+
+func TestLoad_Layered(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "base.yml"), "server:\n  port: 8081\n  cors:\n    allow-all: false\n    origin: [a.com, b.com]\n")
+	// the stage is resolved from explicit files too.
+	writeFile(t, filepath.Join(dir, "explicit", "01-stage.yml"), "stage: dev\n")
+	writeFile(t, filepath.Join(dir, "explicit", "02-metric.json"), `{"metric": {"port": 9093}}`)
+	writeFile(t, filepath.Join(dir, "dev.yml"), "server:\n  port: 8082\n  cors:\n    origin: [c.com]\n")
+	writeFile(t, filepath.Join(dir, "prod.yml"), "server:\n  port: 9999\n")
+	writeFile(t, filepath.Join(dir, "conf.d", "02-metric.yml"), "metric:\n  port: 9092\n")
+	writeFile(t, filepath.Join(dir, "conf.d", "01-metric.yml"), "metric:\n  port: 9091\n  subsystem: api\n")
+	files := Files{
+		Dir:   dir,
+		Paths: []string{filepath.Join(dir, "explicit")},
+	}
+
+	conf, err := Load(files, testEncryption)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "dev", conf.Stage)
+	assert.Equal(t, 8082, conf.Server.Port)
+	// maps are merged and lists are replaced.
+	assert.False(t, conf.Server.Cors.AllowAll)
+	assert.Equal(t, []string{"c.com"}, conf.Server.Cors.Origin)
+	assert.Equal(t, 9093, conf.Metric.Port)
+	assert.Equal(t, "api", conf.Metric.Subsystem)
+
+	origins, err := Origins(files, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, SourceFile+":"+filepath.Join(dir, "base.yml"), origins["server.cors.allow-all"])
+	assert.Equal(t, SourceFile+":"+filepath.Join(dir, "dev.yml"), origins["server.port"])
+	// lists overridden by the stage file are traced with their elements.
+	assert.Equal(t, SourceFile+":"+filepath.Join(dir, "dev.yml"), origins["server.cors.origin"])
+	assert.Equal(t, SourceFile+":"+filepath.Join(dir, "dev.yml"), origins["server.cors.origin.0"])
+	assert.NotContains(t, origins, "server.cors.origin.1")
+	assert.Equal(t, SourceFile+":"+filepath.Join(dir, "conf.d", "01-metric.yml"), origins["metric.subsystem"])
+	assert.Equal(t, SourceFile+":"+filepath.Join(dir, "explicit", "02-metric.json"), origins["metric.port"])
+
+	t.Run("Explicit Base", func(t *testing.T) {
+		// the last occurrence of a file wins.
+		files := Files{Dir: dir, Paths: []string{filepath.Join(dir, "explicit"), filepath.Join(dir, "base.yml")}}
+
+		conf, err := Load(files, testEncryption)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "dev", conf.Stage)
+		assert.Equal(t, 8081, conf.Server.Port)
+		assert.Equal(t, []string{"a.com", "b.com"}, conf.Server.Cors.Origin)
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		// env overrides all config files.
+		t.Setenv(EnvPrefix+"STAGE", "prod")
+		t.Setenv(EnvPrefix+"METRIC_PORT", "9094")
+
+		conf, err := Load(files, testEncryption)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "prod", conf.Stage)
+		assert.Equal(t, 9999, conf.Server.Port)
+		assert.Equal(t, 9094, conf.Metric.Port)
+	})
+
+	t.Run("Unknown Key", func(t *testing.T) {
+		writeFile(t, filepath.Join(dir, "unknown.yml"), "service:\n  host: 10.0.0.1\n")
+
+		_, err := Load(Files{Paths: []string{filepath.Join(dir, "unknown.yml")}}, testEncryption)
+
+		assert.ErrorContains(t, err, "unknown key: service.host")
+	})
+
+	t.Run("Not Exist", func(t *testing.T) {
+		_, err := Load(Files{Paths: []string{filepath.Join(dir, "not-exist.yml")}}, nil)
+
+		assert.ErrorContains(t, err, "not-exist.yml")
+	})
+}
+
+func TestOrigins(t *testing.T) {
+	t.Setenv(EnvPrefix+"METRIC_SUBSYSTEM", "api")
+	t.Setenv(EnvPrefix+"SERVER_PORT", "9090")
+
+	origins, err := Origins(Files{Dir: "../../config"}, map[string]interface{}{"metric.port": 9091})
+
+	assert.NoError(t, err)
+	assert.Equal(t, SourceDefault, origins["metric.namespace"])
+	assert.Equal(t, SourceEnv, origins["metric.subsystem"])
+	// env overrides config files.
+	assert.Equal(t, SourceEnv, origins["server.port"])
+	assert.Equal(t, SourceFile+":../../config/local.yml", origins["logging.encoding"])
+	assert.Equal(t, SourceOverride, origins["metric.port"])
+}
+
+func TestDescribe(t *testing.T) {
+	docs, err := Describe()
+
+	assert.NoError(t, err)
+	described := make(map[string]interface{})
+	for _, doc := range docs {
+		described[doc.Key] = doc.Default
+	}
+	// every default has a key described.
+	for key, value := range defaultConfig {
+		if strings.HasPrefix(key, "db.encryption.keys.") {
+			continue
+		}
+		assert.Contains(t, described, key)
+		assert.Equal(t, value, described[key], key)
+	}
+	assert.Contains(t, described, "db.encryption.keys.*")
+}
+
 func TestMarshalJSON(t *testing.T) {
-	conf, err := Load("", nil)
+	conf, err := Load(Files{}, testEncryption)
 	assert.NoError(t, err)
+	conf.Cache.Redis.Password = "redispass"
 	b, err := json.Marshal(conf)
 	assert.NoError(t, err)
 	var m map[string]interface{}
@@ -86,6 +289,62 @@ func TestMarshalJSON(t *testing.T) {
 
 	assert.Equal(t, "root:****@tcp(127.0.0.1:3306)/mydb?charset=utf8&parseTime=True&multiStatements=true", m["db.data-source-name"])
 	assert.Equal(t, "****", m["server.auth.jwt.key"])
+	assert.Equal(t, "****", m["db.encryption.keys.test"])
+	assert.Equal(t, "****", m["db.encryption.blind-index-key"])
+	assert.Equal(t, "****", m["cache.redis.password"])
+	// lists are not flattened.
+	assert.Equal(t, []interface{}{"stderr"}, m["logging.error-output"])
+	assert.NotContains(t, m, "logging.error-output.0")
+}
+
+func TestLoad_SecretRef(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dsn")
+	dsn := "root:secretpass@tcp(127.0.0.1:3306)/mydb"
+	assert.NoError(t, os.WriteFile(path, []byte(dsn+"\n"), 0600))
+	t.Setenv("TEST_JWT_KEY", "secret-jwt-key")
+	t.Setenv(EnvPrefix+"METRIC_NAMESPACE_FILE", path)
+
+	override := map[string]interface{}{
+		"db.data-source-name": "file://" + path,
+		"server.auth.jwt.key": "env://TEST_JWT_KEY",
+	}
+	for k, v := range testEncryption {
+		override[k] = v
+	}
+	conf, err := Load(Files{}, override)
+
+	assert.NoError(t, err)
+	assert.Equal(t, dsn, conf.DB.DataSourceName)
+	assert.Equal(t, "secret-jwt-key", conf.Server.Auth.JWT.Key)
+	assert.Equal(t, dsn, conf.Metric.Namespace)
+	b, err := json.Marshal(conf)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "secretpass")
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(b, &m))
+	assert.Equal(t, "****", m["db.data-source-name"])
+	assert.Equal(t, "****", m["metric.namespace"])
+
+	t.Run("Reload", func(t *testing.T) {
+		assert.NoError(t, os.WriteFile(path, []byte("root:rotated@tcp(127.0.0.1:3306)/mydb"), 0600))
+
+		assert.NoError(t, cfgloader.Reload(conf.K, conf))
+
+		assert.Equal(t, "root:rotated@tcp(127.0.0.1:3306)/mydb", conf.DB.DataSourceName)
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		override["server.auth.jwt.key"] = "env://TEST_MISSING_JWT_KEY"
+
+		_, err := Load(Files{}, override)
+
+		assert.ErrorContains(t, err, "resolve secret of server.auth.jwt.key")
+	})
+}
+
+func writeFile(t *testing.T, path, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
 }
 
 func equal(t *testing.T, expected interface{}, values ...interface{}) {
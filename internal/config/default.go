@@ -1,11 +1,18 @@
 package config
 
 var defaultConfig = map[string]interface{}{
-	"stage":                      "local",
-	"logging.level":              1,
-	"logging.encoding":           "console",
-	"logging.development":        false,
-	"logging.disable-stacktrace": true,
+	"stage":                       "local",
+	"logging.level":               1,
+	"logging.encoding":            "console",
+	"logging.development":         false,
+	"logging.disable-stacktrace":  true,
+	"logging.sampling.enabled":    false,
+	"logging.sampling.tick":       "1s",
+	"logging.sampling.initial":    100,
+	"logging.sampling.thereafter": 100,
+	"logging.error-output":        []string{"stderr"},
+	"logging.debug.secret":        "",
+	"logging.debug.max-ttl":       "1h",
 
 	"server.port":                 8080,
 	"server.read-timeout":         "5s",
@@ -29,17 +36,34 @@ var defaultConfig = map[string]interface{}{
 	"db.pool.max-idle":     10,
 	"db.pool.max-lifetime": "30m",
 
-	"cache.enabled":             false,
-	"cache.prefix":              "myapp-",
-	"cache.type":                "redis",
-	"cache.ttl":                 "1m",
-	"cache.redis.read-timeout":  "3s",
-	"cache.redis.write-timeout": "3s",
-	"cache.redis.dial-timeout":  "5s",
-	"cache.redis.pool-size":     10,
-	"cache.redis.pool-timeout":  "4s",
-	"cache.redis.max-conn-age":  0,
-	"cache.redis.idle-timeout":  "60s",
+	"cache.enabled":                    false,
+	"cache.prefix":                     "myapp-",
+	"cache.type":                       "redis",
+	"cache.codec":                      "msgpack",
+	"cache.ttl":                        "1m",
+	"cache.ttl-jitter":                 0.1,
+	"cache.negative-ttl":               "5s",
+	"cache.lock-ttl":                   "3s",
+	"cache.load-timeout":               "5s",
+	"cache.redis.read-timeout":         "3s",
+	"cache.redis.write-timeout":        "3s",
+	"cache.redis.dial-timeout":         "5s",
+	"cache.redis.pool-size":            10,
+	"cache.redis.pool-timeout":         "4s",
+	"cache.redis.max-conn-age":         0,
+	"cache.redis.idle-timeout":         "60s",
+	"cache.redis.db":                   0,
+	"cache.redis.tls.enabled":          false,
+	"cache.memory.size":                10000,
+	"cache.local.enabled":              false,
+	"cache.local.size":                 10000,
+	"cache.local.ttl":                  "10s",
+	"cache.local.channel":              "cache-invalidation",
+	"cache.breaker.enabled":            true,
+	"cache.breaker.failure-threshold":  5,
+	"cache.breaker.open-timeout":       "10s",
+	"cache.breaker.half-open-requests": 3,
+	"cache.locker.retry-interval":      "100ms",
 
 	"metric.enabled":   true,
 	"metric.port":      8089,
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/zacscoding/go-rest-template/pkg/cfgloader"
+)
+
+// Files are config files to load in below orders. Later files override earlier ones.
+// 1. "base.yml" in Dir if exists.
+// 2. "<stage>.yml" in Dir if exists. The stage is resolved from defaults, "base.yml", Paths and env.
+// 3. "conf.d/*.yml" in Dir sorted by names.
+// 4. Paths in given order. All files in a directory are loaded sorted by names.
+//
+// A file listed more than once is loaded at its last position, e.g. "base.yml" given in Paths
+// overrides the stage file. Maps are merged by keys and lists are replaced by the last file having them as a whole.
+type Files struct {
+	Dir   string
+	Paths []string
+}
+
+// list returns paths of all config files to load in order without duplicates.
+func (f Files) list(configMap map[string]interface{}) ([]string, error) {
+	var explicit []string
+	for _, path := range f.Paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			explicit = append(explicit, path)
+			continue
+		}
+		files, err := cfgloader.ConfigFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		explicit = append(explicit, files...)
+	}
+	if f.Dir == "" {
+		return explicit, nil
+	}
+
+	var files []string
+	base := filepath.Join(f.Dir, "base.yml")
+	if ok, err := exists(base); err != nil {
+		return nil, err
+	} else if ok {
+		files = append(files, base)
+	}
+	stage, err := resolveStage(append(files, explicit...), configMap)
+	if err != nil {
+		return nil, err
+	}
+	if stage != "" {
+		if filepath.Base(stage) != stage {
+			return nil, fmt.Errorf("invalid stage: %s", stage)
+		}
+		path := filepath.Join(f.Dir, stage+".yml")
+		if ok, err := exists(path); err != nil {
+			return nil, err
+		} else if ok {
+			files = append(files, path)
+		}
+	}
+	included, err := cfgloader.ConfigFiles(filepath.Join(f.Dir, "conf.d"))
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, included...)
+	return dedupe(append(files, explicit...))
+}
+
+// resolveStage returns a stage loaded from defaults, given files, env and configMap.
+func resolveStage(files []string, configMap map[string]interface{}) (string, error) {
+	k := koanf.New(".")
+	if err := k.Load(confmap.Provider(defaultConfig, "."), nil); err != nil {
+		return "", err
+	}
+	var opts []cfgloader.Option
+	for _, file := range files {
+		opts = append(opts, cfgloader.WithConfigFile(file))
+	}
+	opts = append(opts, cfgloader.WithEnv(EnvPrefix))
+	if len(configMap) != 0 {
+		opts = append(opts, cfgloader.WithConfigMap(configMap))
+	}
+	for _, opt := range opts {
+		if err := opt(k); err != nil {
+			return "", err
+		}
+	}
+	return k.String("stage"), nil
+}
+
+// dedupe removes files having the same absolute path with later ones, so the last occurrence wins.
+func dedupe(files []string) ([]string, error) {
+	var (
+		seen   = make(map[string]struct{}, len(files))
+		result = make([]string, len(files))
+		n      = len(files)
+	)
+	for i := len(files) - 1; i >= 0; i-- {
+		abs, err := filepath.Abs(files[i])
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[abs]; ok {
+			continue
+		}
+		seen[abs] = struct{}{}
+		n--
+		result[n] = files[i]
+	}
+	return result[n:], nil
+}
+
+func exists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/zacscoding/go-rest-template/pkg/cfgloader"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Reloader reloads configs on SIGHUP, e.g. "systemctl reload apiserver", so rotated file secrets are applied
+// by hooks registered with OnReload.
+type Reloader struct {
+	conf   *Config
+	logger *zap.SugaredLogger
+
+	mu    sync.Mutex
+	hooks []func(conf *Config) error
+}
+
+// NewReloader returns a new Reloader of given conf loaded by Load which listens SIGHUP while the app is running.
+func NewReloader(lc fx.Lifecycle, conf *Config, logger *zap.SugaredLogger) *Reloader {
+	r := Reloader{conf: conf, logger: logger}
+	var (
+		sigs = make(chan os.Signal, 1)
+		done = make(chan struct{})
+	)
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			signal.Notify(sigs, syscall.SIGHUP)
+			go r.listen(sigs, done)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			signal.Stop(sigs)
+			close(done)
+			return nil
+		},
+	})
+	return &r
+}
+
+// OnReload registers fn called with reloaded configs.
+func (r *Reloader) OnReload(fn func(conf *Config) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, fn)
+}
+
+// Reload resolves secret references of the configs again and calls hooks with them.
+// Hooks are not called if reloaded configs are invalid, e.g. a secret file is missing.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var conf Config
+	if err := cfgloader.Reload(r.conf.K, &conf); err != nil {
+		return err
+	}
+	conf.K = r.conf.K
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+	var result *multierror.Error
+	for _, hook := range r.hooks {
+		result = multierror.Append(result, hook(&conf))
+	}
+	return result.ErrorOrNil()
+}
+
+func (r *Reloader) listen(sigs <-chan os.Signal, done <-chan struct{}) {
+	for {
+		select {
+		case <-sigs:
+			if err := r.Reload(); err != nil {
+				r.logger.Errorw("failed to reload configs", "err", err)
+				continue
+			}
+			r.logger.Info("reloaded configs")
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestReloader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt-key")
+	assert.NoError(t, os.WriteFile(path, []byte("secret-jwt-key\n"), 0600))
+	override := map[string]interface{}{"server.auth.jwt.key": "file://" + path}
+	for k, v := range testEncryption {
+		override[k] = v
+	}
+	conf, err := Load(Files{}, override)
+	assert.NoError(t, err)
+
+	lc := fxtest.NewLifecycle(t)
+	logger, logs := logging.NewObservedLogger(zapcore.InfoLevel)
+	r := NewReloader(lc, conf, logger)
+	keys := make(chan string, 1)
+	r.OnReload(func(conf *Config) error {
+		keys <- conf.Server.Auth.JWT.Key
+		return nil
+	})
+	lc.RequireStart()
+	defer lc.RequireStop()
+
+	// reload rotated secrets on SIGHUP
+	assert.NoError(t, os.WriteFile(path, []byte("rotated-jwt-key\n"), 0600))
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+	select {
+	case key := <-keys:
+		assert.Equal(t, "rotated-jwt-key", key)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configs are not reloaded")
+	}
+	assert.Eventually(t, func() bool {
+		return logs.FilterMessage("reloaded configs").Len() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	// the loaded configs are not changed
+	assert.Equal(t, "secret-jwt-key", conf.Server.Auth.JWT.Key)
+
+	t.Run("Missing Secret", func(t *testing.T) {
+		assert.NoError(t, os.Remove(path))
+
+		assert.ErrorContains(t, r.Reload(), "resolve secret of server.auth.jwt.key")
+		assert.Empty(t, keys)
+	})
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
@@ -11,33 +12,45 @@ import (
 	"github.com/zacscoding/go-rest-template/internal/handler/middleware"
 	"github.com/zacscoding/go-rest-template/internal/model"
 	"github.com/zacscoding/go-rest-template/internal/store"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
 	"github.com/zacscoding/go-rest-template/pkg/utils/authutil"
 )
 
 type AuthController struct {
-	JWTMiddleware *jwt.GinJWTMiddleware
+	jwtMiddleware atomic.Pointer[jwt.GinJWTMiddleware]
 
-	conf      *config.Config
 	userStore store.UserStore
 }
 
 func NewAuthController(conf *config.Config, userStore store.UserStore) (*AuthController, error) {
 	c := AuthController{
-		conf:      conf,
 		userStore: userStore,
 	}
-	if err := c.init(); err != nil {
+	if err := c.Reload(conf); err != nil {
 		return nil, err
 	}
 	return &c, nil
 }
 
 func (c *AuthController) AuthMiddleware() gin.HandlerFunc {
-	return c.JWTMiddleware.MiddlewareFunc()
+	return func(gctx *gin.Context) {
+		c.jwtMiddleware.Load().MiddlewareFunc()(gctx)
+	}
+}
+
+// LoginHandler handles login request "POST /api/v1/login".
+func (c *AuthController) LoginHandler(gctx *gin.Context) {
+	c.jwtMiddleware.Load().LoginHandler(gctx)
+}
+
+// RefreshHandler handles refresh request "POST /api/v1/user/refresh-token".
+func (c *AuthController) RefreshHandler(gctx *gin.Context) {
+	c.jwtMiddleware.Load().RefreshHandler(gctx)
 }
 
-func (c *AuthController) init() error {
-	jwtconf := c.conf.Server.Auth.JWT
+// Reload applies jwt configs of given conf, e.g. a rotated key. Tokens signed with the previous key are rejected.
+func (c *AuthController) Reload(conf *config.Config) error {
+	jwtconf := conf.Server.Auth.JWT
 	m, err := jwt.New(&jwt.GinJWTMiddleware{
 		Realm:       jwtconf.Realm,
 		Key:         []byte(jwtconf.Key),
@@ -64,7 +77,7 @@ func (c *AuthController) init() error {
 	if err != nil {
 		return err
 	}
-	c.JWTMiddleware = m
+	c.jwtMiddleware.Store(m)
 	return nil
 }
 
@@ -86,6 +99,7 @@ func (c *AuthController) identityHandler(gctx *gin.Context) interface{} {
 	if err != nil {
 		return nil
 	}
+	logging.AddFields(ctx, "userId", user.ID)
 	gctx.Request = gctx.Request.WithContext(authutil.WithUserContext(ctx, user))
 	return user
 }
@@ -116,6 +130,7 @@ func (c *AuthController) authenticate(gctx *gin.Context) (interface{}, error) {
 	if user.Disabled {
 		return nil, jwt.ErrFailedAuthentication
 	}
+	logging.AddFields(ctx, "userId", user.ID)
 
 	gctx.Request = gctx.Request.WithContext(authutil.WithUserContext(gctx.Request.Context(), user))
 	user.Sanitize(nil)
@@ -124,8 +139,9 @@ func (c *AuthController) authenticate(gctx *gin.Context) (interface{}, error) {
 
 func (c *AuthController) authorize(data interface{}, _ *gin.Context) bool {
 	// Add authz at here if needed.
-	_, ok := data.(*model.User)
-	return ok
+	u, ok := data.(*model.User)
+	// rejects disabled users having a token issued before.
+	return ok && !u.Disabled
 }
 
 func (c *AuthController) unauthorized(gctx *gin.Context, code int, message string) {
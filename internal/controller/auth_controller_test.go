@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/zacscoding/go-rest-template/internal/config"
+	"github.com/zacscoding/go-rest-template/internal/model"
+	"github.com/zacscoding/go-rest-template/internal/store/mocks"
+	"github.com/zacscoding/go-rest-template/pkg/utils/authutil"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestAuthController(t *testing.T, users ...*model.User) *AuthController {
+	gin.SetMode(gin.TestMode)
+	var conf config.Config
+	conf.Server.Auth.JWT.Realm = "test"
+	conf.Server.Auth.JWT.Key = "secret-jwt-key"
+	conf.Server.Auth.JWT.Timeout = time.Minute
+	conf.Server.Auth.JWT.MaxRefresh = time.Hour
+	userStore := &mocks.UserStore{}
+	for _, u := range users {
+		userStore.On("FindByEmail", mock.Anything, u.Email).Return(u, nil)
+	}
+	c, err := NewAuthController(&conf, userStore)
+	assert.NoError(t, err)
+	return c
+}
+
+func newTestUser(t *testing.T, email string, disabled bool) *model.User {
+	password, err := authutil.EncodePassword("userpass", bcrypt.MinCost)
+	assert.NoError(t, err)
+	return &model.User{ID: 1, Email: email, Password: password, Disabled: disabled}
+}
+
+func TestAuthController_Authenticate(t *testing.T) {
+	c := newTestAuthController(t,
+		newTestUser(t, "user1@email.com", false),
+		newTestUser(t, "disabled@email.com", true),
+	)
+
+	cases := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{name: "Valid", body: `{"email": "user1@email.com", "password": "userpass"}`, valid: true},
+		{name: "Wrong Password", body: `{"email": "user1@email.com", "password": "wrongpass"}`},
+		{name: "Disabled", body: `{"email": "disabled@email.com", "password": "userpass"}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			gctx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/login", strings.NewReader(tc.body))
+			gctx.Request.Header.Set("Content-Type", "application/json")
+
+			user, err := c.authenticate(gctx)
+
+			if !tc.valid {
+				assert.Nil(t, user)
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "user1@email.com", user.(*model.User).Email)
+		})
+	}
+
+	t.Run("Login Disabled", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		gctx, _ := gin.CreateTestContext(rec)
+		gctx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/login",
+			strings.NewReader(`{"email": "disabled@email.com", "password": "userpass"}`))
+		gctx.Request.Header.Set("Content-Type", "application/json")
+
+		c.LoginHandler(gctx)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "token")
+	})
+}
+
+func TestAuthController_Authorize(t *testing.T) {
+	c := newTestAuthController(t)
+
+	cases := []struct {
+		name     string
+		data     interface{}
+		expected bool
+	}{
+		{name: "Enabled", data: newTestUser(t, "user1@email.com", false), expected: true},
+		// users disabled after their tokens are issued.
+		{name: "Disabled", data: newTestUser(t, "disabled@email.com", true), expected: false},
+		{name: "Unknown", data: "user1@email.com", expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			gctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+			assert.Equal(t, tc.expected, c.authorize(tc.data, gctx))
+		})
+	}
+}
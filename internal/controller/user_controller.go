@@ -1,25 +1,31 @@
 package controller
 
 import (
+	"fmt"
+
 	"github.com/gin-gonic/gin"
 	"github.com/zacscoding/go-rest-template/internal/config"
+	"github.com/zacscoding/go-rest-template/internal/handler"
 	"github.com/zacscoding/go-rest-template/internal/handler/apierr"
 	"github.com/zacscoding/go-rest-template/internal/model"
 	"github.com/zacscoding/go-rest-template/internal/store"
 	"github.com/zacscoding/go-rest-template/pkg/database"
 	"github.com/zacscoding/go-rest-template/pkg/logging"
 	"github.com/zacscoding/go-rest-template/pkg/utils/authutil"
+	"go.uber.org/zap"
 )
 
 type UserController struct {
 	conf      *config.Config
 	userStore store.UserStore
+	logger    *zap.SugaredLogger
 }
 
-func NewUserController(conf *config.Config, userStore store.UserStore) (*UserController, error) {
+func NewUserController(conf *config.Config, userStore store.UserStore, logger *zap.SugaredLogger) (*UserController, error) {
 	return &UserController{
 		conf:      conf,
 		userStore: userStore,
+		logger:    logger,
 	}, nil
 }
 
@@ -41,7 +47,7 @@ func (c *UserController) HandleSignUp(gctx *gin.Context) (interface{}, error) {
 
 	password, err := authutil.EncodePassword(req.Password, 0)
 	if err != nil {
-		logging.FromContext(ctx).Errorw("failed to encode password", "err", err)
+		logging.FromContextOr(ctx, c.logger).Errorw("failed to encode password", "err", err)
 		return nil, err
 	}
 
@@ -65,5 +71,47 @@ func (c *UserController) HandleSignUp(gctx *gin.Context) (interface{}, error) {
 // HandleMe handles "GET /api/v1/user/me"
 func (c *UserController) HandleMe(gctx *gin.Context) (interface{}, error) {
 	currentUser := authutil.CurrentUser(gctx.Request.Context())
+	if u, ok := currentUser.(*model.User); ok {
+		gctx.Header(handler.HeaderETag, userETag(u))
+	}
 	return currentUser, nil
 }
+
+type UpdateMeReq struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// HandleUpdateMe handles "PUT /api/v1/user/me".
+// The update is rejected if "If-Match" header is not matched with the current user's ETag.
+func (c *UserController) HandleUpdateMe(gctx *gin.Context) (interface{}, error) {
+	var (
+		ctx = gctx.Request.Context()
+		req UpdateMeReq
+	)
+	if err := gctx.ShouldBind(&req); err != nil {
+		return nil, apierr.ErrInvalidRequest.WithMessage(err.Error())
+	}
+
+	user, ok := authutil.CurrentUser(ctx).(*model.User)
+	if !ok {
+		return nil, apierr.ErrAuthenticationFail
+	}
+	if !handler.MatchesIfMatch(gctx, userETag(user)) {
+		return nil, apierr.ErrPreconditionFailed
+	}
+
+	user.Username = req.Username
+	if err := c.userStore.Save(ctx, user); err != nil {
+		if err != database.ErrStaleObject {
+			return nil, err
+		}
+		return nil, apierr.ErrResourceModified
+	}
+	gctx.Header(handler.HeaderETag, userETag(user))
+	return user, nil
+}
+
+// userETag returns an entity tag of given u user which is changed whenever the user is updated.
+func userETag(u *model.User) string {
+	return fmt.Sprintf(`"%d-%d"`, u.ID, u.Version)
+}
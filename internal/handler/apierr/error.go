@@ -10,6 +10,8 @@ var (
 	ErrResourceNotFound    = New(http.StatusNotFound, "ResourceNotFound", "Resource not found.")
 	ErrAuthenticationFail  = New(http.StatusUnauthorized, "FailedAuthentication", "Auth failed")
 	ErrResourceConflict    = New(http.StatusConflict, "ResourceAlreadyExist", "Resource already exists")
+	ErrResourceModified    = New(http.StatusConflict, "ResourceModified", "Resource has been modified by another request.")
+	ErrPreconditionFailed  = New(http.StatusPreconditionFailed, "PreconditionFailed", "Precondition failed.")
 	ErrInternalServerError = New(http.StatusInternalServerError, "InternalServerError",
 		"There was an error. Please try again later.")
 )
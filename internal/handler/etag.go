@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderETag    = "ETag"     // entity tag response header key
+	HeaderIfMatch = "If-Match" // conditional request header key
+)
+
+// MatchesIfMatch returns true if the "If-Match" header of the request is empty, "*" or contains given etag.
+// Weak entity tags never match because "If-Match" uses the strong comparison.
+func MatchesIfMatch(gctx *gin.Context, etag string) bool {
+	header := gctx.GetHeader(HeaderIfMatch)
+	if header == "" {
+		return true
+	}
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesIfMatch(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name    string
+		ifMatch string
+		etag    string
+		want    bool
+	}{
+		{name: "Empty Header", ifMatch: "", etag: `"1"`, want: true},
+		{name: "Any", ifMatch: "*", etag: `"1"`, want: true},
+		{name: "Matched", ifMatch: `"1"`, etag: `"1"`, want: true},
+		{name: "Matched In List", ifMatch: `"0", "1"`, etag: `"1"`, want: true},
+		{name: "Not Matched", ifMatch: `"2"`, etag: `"1"`, want: false},
+		{name: "Weak", ifMatch: `W/"1"`, etag: `"1"`, want: false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			gctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			gctx.Request, _ = http.NewRequest(http.MethodPut, "http://localhost/foo", nil)
+			if tc.ifMatch != "" {
+				gctx.Request.Header.Set(HeaderIfMatch, tc.ifMatch)
+			}
+
+			assert.Equal(t, tc.want, MatchesIfMatch(gctx, tc.etag))
+		})
+	}
+}
@@ -3,34 +3,68 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
 )
 
 const (
-	XRequestIdKey = "X-Request-ID" // request id header key
+	XRequestIdKey   = "X-Request-ID" // request id header key
+	XDebugLogKey    = "X-Debug-Log"  // debug log token header key
+	TraceParentKey  = "traceparent"  // w3c trace context header key
+	traceParentSize = 55             // size of "version-traceid-parentid-flags"
 )
 
 // RequestIDMiddleware attach request id and logger to context
 // 1. extract request id from header if exists, otherwise generate
-// 2. attach request id to logger and store it to context
-func RequestIDMiddleware() gin.HandlerFunc {
+// 2. attach request id, route, client ip and trace id if exists to given logger and store it to context.
+// other middlewares can add fields to the logger of the request by logging.AddFields.
+// 3. write logs of all levels including sql statements for the request if it has a X-Debug-Log header
+// signed for its request id and verified by given debugSigner. Debug logs are disabled if debugSigner is nil.
+func RequestIDMiddleware(logger *zap.SugaredLogger, debugSigner *logging.DebugSigner) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestId := c.Request.Header.Get(XRequestIdKey)
 		if requestId == "" {
 			requestId = uuid.New().String()
 		}
 
-		logger := logging.DefaultLogger().With("requestId", requestId)
-		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), logger))
+		keysAndValues := []interface{}{"requestId", requestId, "route", c.FullPath(), "clientIP", c.ClientIP()}
+		if traceId := traceID(c.Request.Header.Get(TraceParentKey)); traceId != "" {
+			keysAndValues = append(keysAndValues, "traceId", traceId)
+		}
+		reqLogger := logger
+		if token := c.Request.Header.Get(XDebugLogKey); token != "" && debugSigner != nil {
+			if err := debugSigner.Verify(token, requestId); err != nil {
+				// not warned since anyone can send the header.
+				logger.With(keysAndValues...).Debugw("ignored a debug log header", "err", err)
+			} else {
+				reqLogger = logging.WithDebug(logger)
+				keysAndValues = append(keysAndValues, "debugLog", true)
+			}
+		}
+		c.Request = c.Request.WithContext(logging.WithFields(c.Request.Context(), reqLogger, keysAndValues...))
 		c.Writer.Header().Set(XRequestIdKey, requestId)
 		c.Next()
 	}
 }
 
+// traceID returns a trace id of given traceparent header, e.g. "4bf92f3577b34da6a3ce929d0e0e4736" of
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". Returns empty if the header is invalid.
+func traceID(traceParent string) string {
+	if len(traceParent) < traceParentSize || traceParent[2] != '-' || traceParent[35] != '-' {
+		return ""
+	}
+	traceId := traceParent[3:35]
+	if strings.Trim(traceId, "0123456789abcdef") != "" || strings.Trim(traceId, "0") == "" {
+		return ""
+	}
+	return traceId
+}
+
 // TimeoutMiddleware attach deadline to gin.Request.Context
 func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -47,8 +81,9 @@ func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 	}
 }
 
-// LoggingMiddleware use logging.DefaultLogger() i.e *zap.SugaredLogger with x-request-id
-func LoggingMiddleware(skipPaths ...string) gin.HandlerFunc {
+// LoggingMiddleware logs requests with the logger of the request context i.e *zap.SugaredLogger with x-request-id
+// or given logger if not exists.
+func LoggingMiddleware(logger *zap.SugaredLogger, skipPaths ...string) gin.HandlerFunc {
 	skip := make(map[string]struct{}, len(skipPaths))
 	for _, path := range skipPaths {
 		skip[path] = struct{}{}
@@ -68,7 +103,7 @@ func LoggingMiddleware(skipPaths ...string) gin.HandlerFunc {
 		// process request
 		c.Next()
 
-		logger := logging.FromContext(c.Request.Context())
+		logger := logging.FromContextOr(c.Request.Context(), logger)
 		timestamp := time.Now()
 		latency := timestamp.Sub(start)
 		latencyValue := latency.String()
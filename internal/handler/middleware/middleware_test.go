@@ -8,6 +8,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestRequestIDMiddleware(t *testing.T) {
@@ -24,8 +27,11 @@ func TestRequestIDMiddleware(t *testing.T) {
 	for _, tc := range cases {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			srv := setupRouter(func(c *gin.Engine) {
-				c.Use(RequestIDMiddleware())
+			logger, logs := logging.NewObservedLogger(zapcore.InfoLevel)
+			srv := setupRouterWithHandler(func(c *gin.Engine) {
+				c.Use(RequestIDMiddleware(logger, nil))
+			}, func(c *gin.Context) {
+				logging.FromContext(c.Request.Context()).Info("handle request")
 			})
 
 			res := httptest.NewRecorder()
@@ -41,10 +47,134 @@ func TestRequestIDMiddleware(t *testing.T) {
 			} else {
 				assert.Equal(t, tc.requestID, res.Header().Get(XRequestIdKey))
 			}
+			assert.Equal(t, 1, logs.FilterField(zap.String("requestId", res.Header().Get(XRequestIdKey))).Len())
 		})
 	}
 }
 
+func TestRequestIDMiddleware_Fields(t *testing.T) {
+	logger, logs := logging.NewObservedLogger(zapcore.InfoLevel)
+	srv := setupRouterWithHandler(func(c *gin.Engine) {
+		c.Use(LoggingMiddleware(logger), RequestIDMiddleware(logger, nil), func(c *gin.Context) {
+			logging.AddFields(c.Request.Context(), "userId", 1)
+		})
+	}, func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("handle request")
+	})
+
+	req, _ := http.NewRequest("GET", "http://localhost/foo", nil)
+	req.Header.Set(XRequestIdKey, "request1")
+	req.Header.Set(TraceParentKey, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.RemoteAddr = "10.0.0.1:1234"
+	srv.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := map[string]interface{}{
+		"requestId": "request1",
+		"route":     "/foo",
+		"clientIP":  "10.0.0.1",
+		"traceId":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"userId":    int64(1),
+	}
+	entries := logs.All()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "handle request", entries[0].Message)
+	assert.Equal(t, expected, entries[0].ContextMap())
+	assert.Contains(t, entries[1].Message, "[API]")
+	assert.Equal(t, expected, entries[1].ContextMap())
+}
+
+func TestRequestIDMiddleware_DebugLog(t *testing.T) {
+	signer := logging.NewDebugSigner("debug-secret", time.Hour)
+	token, err := signer.Sign("request1", time.Minute)
+	assert.NoError(t, err)
+	invalid, err := logging.NewDebugSigner("other-secret", time.Hour).Sign("request1", time.Minute)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		signer    *logging.DebugSigner
+		token     string
+		requestId string
+		level     zapcore.Level
+		expected  []string
+	}{
+		{name: "Valid", signer: signer, token: token, requestId: "request1", expected: []string{"debug message", "db message"}},
+		{name: "Invalid", signer: signer, token: invalid, requestId: "request1"},
+		{name: "Invalid Debug", signer: signer, token: invalid, requestId: "request1", level: zapcore.DebugLevel,
+			expected: []string{"ignored a debug log header", "debug message", "db message"}},
+		{name: "OtherRequest", signer: signer, token: token, requestId: "request2"},
+		{name: "NoRequestId", signer: signer, token: token},
+		{name: "Disabled", signer: nil, token: token, requestId: "request1"},
+		{name: "NoHeader", signer: signer, requestId: "request1"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// info level unless given.
+			logger, logs := logging.NewObservedLogger(tc.level)
+			srv := setupRouterWithHandler(func(c *gin.Engine) {
+				c.Use(RequestIDMiddleware(logger, tc.signer))
+			}, func(c *gin.Context) {
+				logging.FromContext(c.Request.Context()).Debug("debug message")
+				logging.FromContext(c.Request.Context()).Named("[DB]").Debug("db message")
+			})
+
+			req, _ := http.NewRequest("GET", "http://localhost/foo", nil)
+			if tc.token != "" {
+				req.Header.Set(XDebugLogKey, tc.token)
+			}
+			if tc.requestId != "" {
+				req.Header.Set(XRequestIdKey, tc.requestId)
+			}
+			srv.ServeHTTP(httptest.NewRecorder(), req)
+
+			var messages []string
+			for _, e := range logs.All() {
+				messages = append(messages, e.Message)
+				if tc.name == "Valid" {
+					assert.Equal(t, true, e.ContextMap()["debugLog"])
+				}
+			}
+			assert.Equal(t, tc.expected, messages)
+		})
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	cases := []struct {
+		traceParent string
+		expected    string
+	}{
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expected: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{traceParent: "", expected: ""},
+		{traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736", expected: ""},
+		{traceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", expected: ""},
+		{traceParent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", expected: ""},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, traceID(tc.traceParent), tc.traceParent)
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	logger, logs := logging.NewObservedLogger(zapcore.InfoLevel)
+	srv := setupRouter(func(c *gin.Engine) {
+		c.Use(LoggingMiddleware(logger, "/skip"))
+	})
+	srv.GET("/skip", func(c *gin.Context) {})
+
+	for _, path := range []string{"/foo", "/skip"} {
+		req, _ := http.NewRequest("GET", "http://localhost"+path, nil)
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+	assert.Contains(t, entries[0].Message, "[API]")
+	assert.Contains(t, entries[0].Message, `"/foo"`)
+}
+
 func TestTimeoutMiddleware(t *testing.T) {
 	timeout := time.Millisecond * 50
 	srv := setupRouterWithHandler(func(c *gin.Engine) {
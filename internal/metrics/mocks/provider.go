@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	cache "github.com/zacscoding/go-rest-template/pkg/cache"
+
 	time "time"
 
 	mock "github.com/stretchr/testify/mock"
@@ -28,6 +30,49 @@ func (_m *Provider) RecordCache(key string, hit bool) {
 	_m.Called(key, hit)
 }
 
+// RecordCacheEviction provides a mock function with given fields: group
+func (_m *Provider) RecordCacheEviction(group string) {
+	_m.Called(group)
+}
+
+// RecordCacheOperation provides a mock function with given fields: op, group, elapsed, errType
+func (_m *Provider) RecordCacheOperation(op string, group string, elapsed time.Duration, errType string) {
+	_m.Called(op, group, elapsed, errType)
+}
+
+// RecordCachePayload provides a mock function with given fields: op, group, size
+func (_m *Provider) RecordCachePayload(op string, group string, size int) {
+	_m.Called(op, group, size)
+}
+
+// RegisterCacheCircuit provides a mock function with given fields: reporter
+func (_m *Provider) RegisterCacheCircuit(reporter cache.CircuitReporter) error {
+	ret := _m.Called(reporter)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(cache.CircuitReporter) error); ok {
+		r0 = rf(reporter)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RegisterCacheStats provides a mock function with given fields: reporter
+func (_m *Provider) RegisterCacheStats(reporter cache.StatsReporter) error {
+	ret := _m.Called(reporter)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(cache.StatsReporter) error); ok {
+		r0 = rf(reporter)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewProvider interface {
 	mock.TestingT
 	Cleanup(func())
@@ -7,10 +7,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/zacscoding/go-rest-template/internal/config"
+	"github.com/zacscoding/go-rest-template/pkg/cache"
 )
 
 //go:generate mockery --name Provider --filename provider.go
 type Provider interface {
+	// Recorder records latency, errors, payload size of cache operations and evictions of the memory cache.
+	cache.Recorder
+
 	// RecordApiCount increases count of api request with given code, method, path labels
 	RecordApiCount(code int, method, path string)
 
@@ -19,6 +23,12 @@ type Provider interface {
 
 	// RecordCache increases count of cache request with given key, hit
 	RecordCache(key string, hit bool)
+
+	// RegisterCacheStats exposes hits and misses of each cache tier reported by given reporter.
+	RegisterCacheStats(reporter cache.StatsReporter) error
+
+	// RegisterCacheCircuit exposes a circuit state of the cache reported by given reporter.
+	RegisterCacheCircuit(reporter cache.CircuitReporter) error
 }
 
 type provider struct {
@@ -35,8 +45,12 @@ type apiMetricsProvider struct {
 }
 
 type cacheMetricsProvider struct {
-	cacheTotalCounter *prometheus.CounterVec
-	cacheHitCounter   *prometheus.CounterVec
+	cacheTotalCounter     *prometheus.CounterVec
+	cacheHitCounter       *prometheus.CounterVec
+	operationLatency      *prometheus.HistogramVec
+	operationErrorCounter *prometheus.CounterVec
+	payloadSize           *prometheus.HistogramVec
+	evictionCounter       *prometheus.CounterVec
 }
 
 // NewProvider returns a new Provider with given conf config.Config.
@@ -88,6 +102,44 @@ func NewProvider(conf *config.Config) Provider {
 				},
 				[]string{"key"},
 			),
+			operationLatency: promauto.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Namespace: ns,
+					Subsystem: ss,
+					Name:      "cache_operation_latency",
+					Help:      "Elapsed time of cache operations in milliseconds",
+					Buckets:   []float64{0.5, 1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000},
+				},
+				[]string{"op", "group"},
+			),
+			operationErrorCounter: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: ns,
+					Subsystem: ss,
+					Name:      "cache_operation_error",
+					Help:      "Total count of failed cache operations",
+				},
+				[]string{"op", "group", "type"},
+			),
+			payloadSize: promauto.NewHistogramVec(
+				prometheus.HistogramOpts{
+					Namespace: ns,
+					Subsystem: ss,
+					Name:      "cache_payload_size",
+					Help:      "Size of cached items in bytes",
+					Buckets:   prometheus.ExponentialBuckets(64, 4, 8),
+				},
+				[]string{"op", "group"},
+			),
+			evictionCounter: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: ns,
+					Subsystem: ss,
+					Name:      "cache_eviction",
+					Help:      "Total count of items evicted from the memory cache to free capacity",
+				},
+				[]string{"group"},
+			),
 		},
 	}
 	return &p
@@ -108,3 +160,120 @@ func (p *provider) RecordCache(key string, hit bool) {
 		p.cacheMetricsProvider.cacheHitCounter.WithLabelValues(key).Inc()
 	}
 }
+
+func (p *provider) RecordCacheOperation(op, group string, elapsed time.Duration, errType string) {
+	mills := float64(elapsed.Microseconds()) / 1000
+	p.cacheMetricsProvider.operationLatency.WithLabelValues(op, group).Observe(mills)
+	if errType != "" {
+		p.cacheMetricsProvider.operationErrorCounter.WithLabelValues(op, group, errType).Inc()
+	}
+}
+
+func (p *provider) RecordCachePayload(op, group string, size int) {
+	p.cacheMetricsProvider.payloadSize.WithLabelValues(op, group).Observe(float64(size))
+}
+
+func (p *provider) RecordCacheEviction(group string) {
+	p.cacheMetricsProvider.evictionCounter.WithLabelValues(group).Inc()
+}
+
+func (p *provider) RegisterCacheStats(reporter cache.StatsReporter) error {
+	return prometheus.Register(&cacheStatsCollector{
+		reporter: reporter,
+		hitDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(p.namespace, p.subsystem, "cache_tier_hit"),
+			"Total cache hit count of each tier",
+			[]string{"tier"}, nil,
+		),
+		missDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(p.namespace, p.subsystem, "cache_tier_miss"),
+			"Total cache miss count of each tier",
+			[]string{"tier"}, nil,
+		),
+		decodeErrorDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(p.namespace, p.subsystem, "cache_decode_error"),
+			"Total count of cached items failed to decode",
+			nil, nil,
+		),
+		poolHitDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(p.namespace, p.subsystem, "cache_redis_pool_hit"),
+			"Total count of free connections found in the redis pool",
+			nil, nil,
+		),
+		poolMissDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(p.namespace, p.subsystem, "cache_redis_pool_miss"),
+			"Total count of free connections not found in the redis pool",
+			nil, nil,
+		),
+		poolTimeoutDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(p.namespace, p.subsystem, "cache_redis_pool_timeout"),
+			"Total count of wait timeouts for a connection of the redis pool",
+			nil, nil,
+		),
+		poolTotalConnsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(p.namespace, p.subsystem, "cache_redis_pool_total_conns"),
+			"Number of total connections in the redis pool",
+			nil, nil,
+		),
+		poolIdleConnsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(p.namespace, p.subsystem, "cache_redis_pool_idle_conns"),
+			"Number of idle connections in the redis pool",
+			nil, nil,
+		),
+	})
+}
+
+func (p *provider) RegisterCacheCircuit(reporter cache.CircuitReporter) error {
+	return prometheus.Register(prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: p.namespace,
+			Subsystem: p.subsystem,
+			Name:      "cache_circuit_state",
+			Help:      "State of cache circuit breaker. 0: closed, 1: half-open, 2: open",
+		},
+		func() float64 {
+			return float64(reporter.CircuitState())
+		},
+	))
+}
+
+// cacheStatsCollector collects cache.Stats from reporter when scraped.
+type cacheStatsCollector struct {
+	reporter        cache.StatsReporter
+	hitDesc         *prometheus.Desc
+	missDesc        *prometheus.Desc
+	decodeErrorDesc *prometheus.Desc
+
+	poolHitDesc        *prometheus.Desc
+	poolMissDesc       *prometheus.Desc
+	poolTimeoutDesc    *prometheus.Desc
+	poolTotalConnsDesc *prometheus.Desc
+	poolIdleConnsDesc  *prometheus.Desc
+}
+
+func (c *cacheStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hitDesc
+	ch <- c.missDesc
+	ch <- c.decodeErrorDesc
+	ch <- c.poolHitDesc
+	ch <- c.poolMissDesc
+	ch <- c.poolTimeoutDesc
+	ch <- c.poolTotalConnsDesc
+	ch <- c.poolIdleConnsDesc
+}
+
+func (c *cacheStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.reporter.Stats()
+	ch <- prometheus.MustNewConstMetric(c.hitDesc, prometheus.CounterValue, float64(stats.LocalHits), "local")
+	ch <- prometheus.MustNewConstMetric(c.missDesc, prometheus.CounterValue, float64(stats.LocalMisses), "local")
+	ch <- prometheus.MustNewConstMetric(c.hitDesc, prometheus.CounterValue, float64(stats.RedisHits), "redis")
+	ch <- prometheus.MustNewConstMetric(c.missDesc, prometheus.CounterValue, float64(stats.RedisMisses), "redis")
+	ch <- prometheus.MustNewConstMetric(c.decodeErrorDesc, prometheus.CounterValue, float64(stats.DecodeErrors))
+	if pool := stats.Pool; pool != nil {
+		ch <- prometheus.MustNewConstMetric(c.poolHitDesc, prometheus.CounterValue, float64(pool.Hits))
+		ch <- prometheus.MustNewConstMetric(c.poolMissDesc, prometheus.CounterValue, float64(pool.Misses))
+		ch <- prometheus.MustNewConstMetric(c.poolTimeoutDesc, prometheus.CounterValue, float64(pool.Timeouts))
+		ch <- prometheus.MustNewConstMetric(c.poolTotalConnsDesc, prometheus.GaugeValue, float64(pool.TotalConns))
+		ch <- prometheus.MustNewConstMetric(c.poolIdleConnsDesc, prometheus.GaugeValue, float64(pool.IdleConns))
+	}
+}
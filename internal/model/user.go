@@ -19,14 +19,19 @@ func (r Role) String() string {
 }
 
 type User struct {
-	ID        uint      `json:"id" gorm:"column:id"`
-	Username  string    `json:"username" gorm:"column:username;"`
-	Email     string    `json:"email" gorm:"column:email;"`
-	Password  string    `json:"-" gorm:"column:password;"`
-	RolesAll  string    `json:"-" gorm:"column:roles;"`
-	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
-	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
-	Disabled  bool      `json:"-" gorm:"column:disabled;"`
+	ID         uint           `json:"id" gorm:"column:id"`
+	Username   string         `json:"username" gorm:"column:username;"`
+	Email      string         `json:"email" gorm:"column:email;serializer:encrypt"`
+	EmailIndex string         `json:"-" gorm:"column:email_bidx;blindindex:Email"`
+	Password   string         `json:"-" gorm:"column:password;"`
+	RolesAll   string         `json:"-" gorm:"column:roles;"`
+	CreatedAt  time.Time      `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt  time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+	CreatedBy  uint           `json:"-" gorm:"column:created_by"`
+	UpdatedBy  uint           `json:"-" gorm:"column:updated_by"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
+	Disabled   bool           `json:"-" gorm:"column:disabled;"`
+	Version    uint           `json:"-" gorm:"column:version;"`
 
 	Roles    []string          `json:"-" gorm:"-"`
 	RolesMap map[Role]struct{} `json:"-" gorm:"-"`
@@ -36,6 +41,14 @@ func (u *User) GetID() uint {
 	return u.ID
 }
 
+func (u *User) GetVersion() uint {
+	return u.Version
+}
+
+func (u *User) SetVersion(version uint) {
+	u.Version = version
+}
+
 func (u *User) ToModel() interface{} {
 	return u
 }
@@ -44,8 +57,14 @@ func (u *User) TableName() string {
 	return "users"
 }
 
-// BeforeCreate sets RolesAll field in this u User.
-func (u *User) BeforeCreate(_ *gorm.DB) error {
+// HasRole returns true if this u User has given role.
+func (u *User) HasRole(role Role) bool {
+	_, ok := u.RolesMap[role]
+	return ok
+}
+
+// BeforeSave sets RolesAll field in this u User.
+func (u *User) BeforeSave(_ *gorm.DB) error {
 	u.RolesAll = strings.Join(u.Roles, " ")
 	return nil
 }
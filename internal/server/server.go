@@ -17,11 +17,27 @@ import (
 	"github.com/zacscoding/go-rest-template/internal/handler"
 	"github.com/zacscoding/go-rest-template/internal/handler/middleware"
 	"github.com/zacscoding/go-rest-template/internal/metrics"
+	"github.com/zacscoding/go-rest-template/pkg/cache"
+	"github.com/zacscoding/go-rest-template/pkg/database"
 	"github.com/zacscoding/go-rest-template/pkg/logging"
 	"github.com/zacscoding/go-rest-template/pkg/version"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+type versionResponse struct {
+	version.Version
+	// Schema is the database schema status at startup.
+	Schema *database.SchemaStatus `json:"schema,omitempty"`
+}
+
+type readinessResponse struct {
+	Status string `json:"status"`
+	// Components are statuses of dependencies. The cache is "disabled", "up" or a state of its circuit.
+	Components map[string]string `json:"components"`
+}
+
 type Server struct {
 	apiserver    *http.Server
 	metricserver *http.Server
@@ -31,6 +47,11 @@ type Server struct {
 	metricEngine *gin.Engine
 
 	conf           *config.Config
+	logger         *zap.SugaredLogger
+	levels         *logging.Levels
+	schemaStatus   *database.SchemaStatus
+	db             *gorm.DB
+	cacher         cache.Cacher
 	mp             metrics.Provider
 	authController *controller.AuthController
 	userController *controller.UserController
@@ -39,6 +60,11 @@ type Server struct {
 func NewServer(
 	lc fx.Lifecycle,
 	conf *config.Config,
+	logger *zap.SugaredLogger,
+	levels *logging.Levels,
+	schemaStatus *database.SchemaStatus,
+	db *gorm.DB,
+	cacher cache.Cacher,
 	mp metrics.Provider,
 	authController *controller.AuthController,
 	userController *controller.UserController,
@@ -46,6 +72,11 @@ func NewServer(
 	gin.SetMode(gin.ReleaseMode)
 	srv := Server{
 		conf:           conf,
+		logger:         logger,
+		levels:         levels,
+		schemaStatus:   schemaStatus,
+		db:             db,
+		cacher:         cacher,
 		apiEngine:      gin.New(),
 		mp:             mp,
 		authController: authController,
@@ -61,12 +92,12 @@ func NewServer(
 		corscfg.AllowOrigins = conf.Server.Cors.Origin
 	}
 	srv.apiEngine.Use(
-		middleware.LoggingMiddleware("/healthz", "/version", "/metrics"),
+		middleware.LoggingMiddleware(logger, "/healthz", "/readyz", "/version", "/metrics"),
 		gin.Recovery(),
 		cors.New(corscfg),
-		middleware.RequestIDMiddleware(),
+		middleware.RequestIDMiddleware(logger, conf.Logging.DebugSigner()),
 		middleware.TimeoutMiddleware(conf.Server.WriteTimeout),
-		metrics.NewMiddleware(srv.mp, "/version", "/metrics"),
+		metrics.NewMiddleware(srv.mp, "/readyz", "/version", "/metrics"),
 	)
 	if conf.Server.Docs.Enabled {
 		srv.apiEngine.StaticFile("/docs/docs.html", conf.Server.Docs.Path)
@@ -93,11 +124,11 @@ func NewServer(
 
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			logging.FromContext(ctx).Infof("Start to rest api server :%d", srv.conf.Server.Port)
+			srv.logger.Infof("Start to rest api server :%d", srv.conf.Server.Port)
 			return srv.Start()
 		},
 		OnStop: func(ctx context.Context) error {
-			logging.FromContext(ctx).Infof("Stopped rest api server")
+			srv.logger.Infof("Stopped rest api server")
 			return srv.Stop(ctx)
 		},
 	})
@@ -111,14 +142,14 @@ func (srv *Server) Start() error {
 	go func() {
 		err := srv.apiserver.ListenAndServe()
 		if err != nil && err != http.ErrServerClosed {
-			logging.DefaultLogger().Fatalw("failed to close http server", "err", err)
+			srv.logger.Fatalw("failed to close http server", "err", err)
 		}
 	}()
 	if srv.metricserver != nil {
 		go func() {
 			err := srv.metricserver.ListenAndServe()
 			if err != nil && err != http.ErrServerClosed {
-				logging.DefaultLogger().Fatalw("failed to close http metric server", "err", err)
+				srv.logger.Fatalw("failed to close http metric server", "err", err)
 			}
 		}()
 	}
@@ -155,29 +186,67 @@ func (srv *Server) RouteAPI() error {
 func (srv *Server) routeAPI() error {
 	// Route common apis
 	srv.apiEngine.GET("version", func(gctx *gin.Context) {
-		gctx.JSON(http.StatusOK, version.Get())
+		gctx.JSON(http.StatusOK, versionResponse{Version: version.Get(), Schema: srv.schemaStatus})
 	})
+	srv.apiEngine.GET("readyz", srv.handleReadiness)
 
 	// Route v1
 	v1 := srv.apiEngine.Group("/api/v1")
 
 	anonymousGroup := v1.Group("")
-	anonymousGroup.POST("login", srv.authController.JWTMiddleware.LoginHandler)
+	anonymousGroup.POST("login", srv.authController.LoginHandler)
 	anonymousGroup.POST("signup", handler.Wrap(srv.userController.HandleSignUp))
 
 	authGroup := v1.Group("")
 	authGroup.Use(srv.authController.AuthMiddleware())
 
 	userGroup := authGroup.Group("user")
-	userGroup.POST("refresh-token", srv.authController.JWTMiddleware.RefreshHandler)
+	userGroup.POST("refresh-token", srv.authController.RefreshHandler)
 	userGroup.GET("me", handler.Wrap(srv.userController.HandleMe))
+	userGroup.PUT("me", handler.Wrap(srv.userController.HandleUpdateMe))
 	return nil
 }
 
+// handleReadiness reports statuses of dependencies.
+// The server is ready while the cache circuit is open since the cache fails open to the database.
+func (srv *Server) handleReadiness(gctx *gin.Context) {
+	var (
+		code = http.StatusOK
+		res  = readinessResponse{Status: "ok", Components: map[string]string{"db": "up", "cache": "disabled"}}
+	)
+	sqlDB, err := srv.db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(gctx.Request.Context())
+	}
+	if err != nil {
+		logging.FromContextOr(gctx.Request.Context(), srv.logger).Warnw("failed to ping database", "err", err)
+		code, res.Status, res.Components["db"] = http.StatusServiceUnavailable, "unavailable", "down"
+	}
+	if srv.cacher != nil {
+		res.Components["cache"] = "up"
+		if reporter, ok := srv.cacher.(cache.CircuitReporter); ok {
+			res.Components["cache"] = reporter.CircuitState().String()
+		}
+	}
+	gctx.JSON(code, res)
+}
+
 func (srv *Server) routeMetricAPI() error {
 	if !srv.conf.Metric.Enabled {
 		return nil
 	}
 	srv.metricEngine.GET("metrics", gin.WrapH(promhttp.Handler()))
+	// admin APIs are served only on the metric port not exposed to clients.
+	if srv.metricserver == nil {
+		srv.logger.Warn("admin APIs are disabled since the metric port is same as the server port")
+		return nil
+	}
+	// changes of levels are logged by the logger of the server.
+	levels := func(gctx *gin.Context) {
+		gctx.Request = gctx.Request.WithContext(logging.WithLogger(gctx.Request.Context(), srv.logger))
+		srv.levels.ServeHTTP(gctx.Writer, gctx.Request)
+	}
+	srv.metricEngine.GET("admin/log/level", levels)
+	srv.metricEngine.PUT("admin/log/level", levels)
 	return nil
 }
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/zacscoding/go-rest-template/internal/metrics"
+	"github.com/zacscoding/go-rest-template/pkg/cache"
+	"github.com/zacscoding/go-rest-template/pkg/database"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
+)
+
+// cacheRepository decorates a Repository to cache entities by id.
+// Cached entities are evicted whenever they are written through this repository.
+type cacheRepository[T any, PT EntityPtr[T]] struct {
+	ns       cache.Namespace
+	cacher   cache.Cacher
+	mp       metrics.Provider
+	logger   *zap.SugaredLogger
+	delegate Repository[T]
+}
+
+func newCacheRepository[T any, PT EntityPtr[T]](name string,
+	cacher cache.Cacher,
+	mp metrics.Provider,
+	logger *zap.SugaredLogger,
+	delegate Repository[T],
+) (Repository[T], error) {
+	if cacher == nil {
+		return nil, errors.New("require cacher")
+	}
+	return &cacheRepository[T, PT]{
+		// keys are versioned by the schema of T.
+		ns:       cache.NewNamespace(name+"-by-id", *new(T)),
+		cacher:   cacher,
+		mp:       mp,
+		logger:   logger,
+		delegate: delegate,
+	}, nil
+}
+
+func (r *cacheRepository[T, PT]) Create(ctx context.Context, e *T) error {
+	return r.delegate.Create(ctx, e)
+}
+
+func (r *cacheRepository[T, PT]) Update(ctx context.Context, e *T) error {
+	defer r.evict(ctx, PT(e).GetID())
+	return r.delegate.Update(ctx, e)
+}
+
+func (r *cacheRepository[T, PT]) Delete(ctx context.Context, e *T) error {
+	defer r.evict(ctx, PT(e).GetID())
+	return r.delegate.Delete(ctx, e)
+}
+
+func (r *cacheRepository[T, PT]) FindByID(ctx context.Context, id uint, opts ...database.QueryOption) (*T, error) {
+	// deleted entities are not cached.
+	if withDeleted(ctx, opts...) {
+		return r.delegate.FindByID(ctx, id, opts...)
+	}
+	var (
+		item     T
+		cacheHit = true
+	)
+	err := r.cacher.Fetch(ctx, r.idKey(id), &item, func(ctx context.Context) (interface{}, error) {
+		cacheHit = false
+		return r.delegate.FindByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.mp.RecordCache(r.ns.Name(), cacheHit)
+	return &item, nil
+}
+
+func (r *cacheRepository[T, PT]) FindOne(ctx context.Context, spec Spec, opts ...database.QueryOption) (*T, error) {
+	return r.delegate.FindOne(ctx, spec, opts...)
+}
+
+func (r *cacheRepository[T, PT]) List(ctx context.Context, spec Spec, opts ...database.QueryOption) (*PageResult[T], error) {
+	return r.delegate.List(ctx, spec, opts...)
+}
+
+// evict removes a cached entity with given id after the transaction in the context is committed.
+func (r *cacheRepository[T, PT]) evict(ctx context.Context, id uint) {
+	database.AfterCommit(ctx, func() {
+		if err := r.cacher.Delete(ctx, r.idKey(id)); err != nil {
+			logging.FromContextOr(ctx, r.logger).Warnw("failed to evict a cached entity", "key", r.idKey(id), "err", err)
+		}
+	})
+}
+
+func (r *cacheRepository[T, PT]) idKey(id uint) string {
+	return r.ns.Key(strconv.FormatUint(uint64(id), 10))
+}
@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/zacscoding/go-rest-template/internal/model"
+	"github.com/zacscoding/go-rest-template/pkg/database"
+)
+
+// userRepositoryMock is a Repository[model.User] records calls.
+type userRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *userRepositoryMock) Create(ctx context.Context, e *model.User) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *userRepositoryMock) Update(ctx context.Context, e *model.User) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *userRepositoryMock) Delete(ctx context.Context, e *model.User) error {
+	return m.Called(ctx, e).Error(0)
+}
+
+func (m *userRepositoryMock) FindByID(ctx context.Context, id uint, _ ...database.QueryOption) (*model.User, error) {
+	ret := m.Called(ctx, id)
+	u, _ := ret.Get(0).(*model.User)
+	return u, ret.Error(1)
+}
+
+func (m *userRepositoryMock) FindOne(ctx context.Context, spec Spec, _ ...database.QueryOption) (*model.User, error) {
+	ret := m.Called(ctx, spec)
+	u, _ := ret.Get(0).(*model.User)
+	return u, ret.Error(1)
+}
+
+func (m *userRepositoryMock) List(ctx context.Context, spec Spec, _ ...database.QueryOption) (*PageResult[model.User], error) {
+	ret := m.Called(ctx, spec)
+	p, _ := ret.Get(0).(*PageResult[model.User])
+	return p, ret.Error(1)
+}
+
+func (s *CacheStoreSuite) TestRepository_FindByID_CacheHit() {
+	user := model.User{ID: 1, Email: "user@email.com", Version: 1}
+	repoMock := &userRepositoryMock{}
+	repoMock.On("FindByID", mock.Anything, user.ID).Return(&user, nil)
+	s.mpMock.On("RecordCache", mock.Anything, mock.Anything)
+	repo, err := newCacheRepository[model.User]("user", s.cacher, s.mpMock, s.logger, repoMock)
+	s.NoError(err)
+
+	_, err = repo.FindByID(context.TODO(), user.ID)
+	s.NoError(err)
+	find, err := repo.FindByID(context.TODO(), user.ID)
+
+	s.NoError(err)
+	s.Equal(user.Email, find.Email)
+	repoMock.AssertNumberOfCalls(s.T(), "FindByID", 1)
+	s.mpMock.AssertCalled(s.T(), "RecordCache", "user-by-id", false)
+	s.mpMock.AssertCalled(s.T(), "RecordCache", "user-by-id", true)
+}
+
+func (s *CacheStoreSuite) TestRepository_Update_Evict() {
+	user := model.User{ID: 1, Email: "user@email.com", Version: 1}
+	repoMock := &userRepositoryMock{}
+	repoMock.On("FindByID", mock.Anything, user.ID).Return(&user, nil)
+	repoMock.On("Update", mock.Anything, &user).Return(nil)
+	s.mpMock.On("RecordCache", mock.Anything, mock.Anything)
+	repo, err := newCacheRepository[model.User]("user", s.cacher, s.mpMock, s.logger, repoMock)
+	s.NoError(err)
+	_, err = repo.FindByID(context.TODO(), user.ID)
+	s.NoError(err)
+
+	s.NoError(repo.Update(context.TODO(), &user))
+	_, err = repo.FindByID(context.TODO(), user.ID)
+
+	s.NoError(err)
+	repoMock.AssertNumberOfCalls(s.T(), "FindByID", 2)
+}
+
+func (s *CacheStoreSuite) TestNewUserStore_CacheRepository() {
+	us, err := NewUserStore(s.conf, nil, s.enc, s.cacher, s.mpMock, s.logger)
+	s.NoError(err)
+
+	s.IsType(&userCacheStore{}, us)
+	delegate, ok := us.(*userCacheStore).delegate.(*userStore)
+	s.True(ok)
+	s.IsType(&cacheRepository[model.User, *model.User]{}, delegate.repo)
+}
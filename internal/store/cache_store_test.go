@@ -2,20 +2,31 @@ package store
 
 import (
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/zacscoding/go-rest-template/internal/config"
 	metricsMocks "github.com/zacscoding/go-rest-template/internal/metrics/mocks"
+	"github.com/zacscoding/go-rest-template/internal/model"
 	"github.com/zacscoding/go-rest-template/internal/store/mocks"
 	"github.com/zacscoding/go-rest-template/pkg/cache"
+	"github.com/zacscoding/go-rest-template/pkg/database"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type CacheStoreSuite struct {
 	suite.Suite
 	conf         *config.Config
+	enc          *database.Encryptor
 	cacher       cache.Cacher
 	cacheCloseFn cache.CloseFn
 	mpMock       *metricsMocks.Provider
+	logger       *zap.SugaredLogger
+	logs         *observer.ObservedLogs
 
 	userStore     *userCacheStore
 	userStoreMock *mocks.UserStore
@@ -26,19 +37,24 @@ func TestCacheStoreSuite(t *testing.T) {
 }
 
 func (s *CacheStoreSuite) BeforeTest(_, _ string) {
-	conf, err := config.Load("", nil)
+	conf, err := config.Load(config.Files{}, testEncryption)
 	s.NoError(err)
 
 	s.conf = conf
+	s.enc, err = database.NewEncryptor(&conf.DB)
+	s.NoError(err)
 	s.mpMock = &metricsMocks.Provider{}
+	s.logger, s.logs = logging.NewObservedLogger(zapcore.DebugLevel)
 	cacher, cacherCloseFn, err := cache.NewTestMemoryRedisCacher(s.T())
 	s.NoError(err)
 	s.cacher, s.cacheCloseFn = cacher, cacherCloseFn
 
 	s.userStoreMock = &mocks.UserStore{}
 	s.userStore = &userCacheStore{
+		enc:      s.enc,
 		cacher:   s.cacher,
 		mp:       s.mpMock,
+		logger:   s.logger,
 		delegate: s.userStoreMock,
 	}
 }
@@ -48,3 +64,44 @@ func (s *CacheStoreSuite) AfterTest(_, _ string) {
 		s.cacheCloseFn()
 	}
 }
+
+func TestUserCodecs(t *testing.T) {
+	user := model.User{
+		ID:         1,
+		Username:   "user1",
+		Email:      "user1@email.com",
+		EmailIndex: "index1",
+		Password:   "userpass",
+		RolesAll:   string(model.RoleUser),
+		CreatedAt:  time.Unix(1700000000, 0).UTC(),
+		UpdatedAt:  time.Unix(1700000001, 0).UTC(),
+		CreatedBy:  2,
+		UpdatedBy:  3,
+		Disabled:   true,
+		Version:    4,
+		Roles:      []string{string(model.RoleUser)},
+	}
+
+	for _, name := range []string{cache.CodecMsgpack, cache.CodecJSON, cache.CodecGob} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := cache.NewCodec(name)
+			assert.NoError(t, err)
+
+			b, err := codec.Marshal(&user)
+			assert.NoError(t, err)
+			var find model.User
+			assert.NoError(t, codec.Unmarshal(b, &find))
+
+			// fields hidden from responses are cached too.
+			assert.Equal(t, user.EmailIndex, find.EmailIndex)
+			assert.Equal(t, user.Password, find.Password)
+			assert.Equal(t, user.Disabled, find.Disabled)
+			assert.Equal(t, user.Version, find.Version)
+			assert.Equal(t, user.Roles, find.Roles)
+			assert.Equal(t, user.RolesAll, find.RolesAll)
+			assert.Equal(t, user.CreatedBy, find.CreatedBy)
+			assert.True(t, user.CreatedAt.Equal(find.CreatedAt))
+			assert.Equal(t, user.Email, find.Email)
+		})
+	}
+}
@@ -5,7 +5,10 @@ package mocks
 import (
 	context "context"
 
+	database "github.com/zacscoding/go-rest-template/pkg/database"
+
 	mock "github.com/stretchr/testify/mock"
+
 	model "github.com/zacscoding/go-rest-template/internal/model"
 )
 
@@ -14,25 +17,46 @@ type UserStore struct {
 	mock.Mock
 }
 
-// FindByEmail provides a mock function with given fields: ctx, email
-func (_m *UserStore) FindByEmail(ctx context.Context, email string) (*model.User, error) {
-	ret := _m.Called(ctx, email)
+// Delete provides a mock function with given fields: ctx, u
+func (_m *UserStore) Delete(ctx context.Context, u *model.User) error {
+	ret := _m.Called(ctx, u)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.User) error); ok {
+		r0 = rf(ctx, u)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindByEmail provides a mock function with given fields: ctx, email, opts
+func (_m *UserStore) FindByEmail(ctx context.Context, email string, opts ...database.QueryOption) (*model.User, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, email)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 *model.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*model.User, error)); ok {
-		return rf(ctx, email)
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...database.QueryOption) (*model.User, error)); ok {
+		return rf(ctx, email, opts...)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *model.User); ok {
-		r0 = rf(ctx, email)
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...database.QueryOption) *model.User); ok {
+		r0 = rf(ctx, email, opts...)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, email)
+	if rf, ok := ret.Get(1).(func(context.Context, string, ...database.QueryOption) error); ok {
+		r1 = rf(ctx, email, opts...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -0,0 +1,228 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zacscoding/go-rest-template/internal/metrics"
+	"github.com/zacscoding/go-rest-template/pkg/cache"
+	"github.com/zacscoding/go-rest-template/pkg/database"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Entity is a model stored by Repository which is identified by GetID.
+type Entity interface {
+	GetID() uint
+}
+
+// EntityPtr is a pointer of T which implements Entity.
+type EntityPtr[T any] interface {
+	*T
+	Entity
+}
+
+// Versioned is an Entity updated with optimistic locking by Repository.
+type Versioned interface {
+	GetVersion() uint
+	SetVersion(version uint)
+}
+
+// Repository provides common operations of T entities.
+type Repository[T any] interface {
+	// Create creates a given e entity.
+	Create(ctx context.Context, e *T) error
+
+	// Update updates all fields of a given e entity.
+	// Versioned entity is updated only if its version is matched, otherwise database.ErrStaleObject is returned.
+	Update(ctx context.Context, e *T) error
+
+	// Delete deletes a given e entity. The entity having gorm.DeletedAt field is soft deleted.
+	// Versioned entity is deleted only if its version is matched, otherwise database.ErrStaleObject is returned.
+	Delete(ctx context.Context, e *T) error
+
+	// FindByID returns an entity with given id if exists, otherwise database.ErrRecordNotFound.
+	FindByID(ctx context.Context, id uint, opts ...database.QueryOption) (*T, error)
+
+	// FindOne returns the first entity matched with given spec if exists, otherwise database.ErrRecordNotFound.
+	// Page of the spec is ignored.
+	FindOne(ctx context.Context, spec Spec, opts ...database.QueryOption) (*T, error)
+
+	// List returns a page of entities matched with given spec.
+	List(ctx context.Context, spec Spec, opts ...database.QueryOption) (*PageResult[T], error)
+}
+
+// NewRepository returns a new Repository of T entities.
+// The returned Repository caches entities by id with given name if cacher is not nil.
+// Errors are logged by the logger of the context if exists, otherwise given logger.
+func NewRepository[T any, PT EntityPtr[T]](name string,
+	db *gorm.DB,
+	cacher cache.Cacher,
+	mp metrics.Provider,
+	logger *zap.SugaredLogger,
+) (Repository[T], error) {
+	repo := &repository[T, PT]{name: name, db: db, logger: logger}
+	if cacher == nil {
+		return repo, nil
+	}
+	return newCacheRepository[T, PT](name, cacher, mp, logger, repo)
+}
+
+type repository[T any, PT EntityPtr[T]] struct {
+	name   string
+	db     *gorm.DB
+	logger *zap.SugaredLogger
+}
+
+func (r *repository[T, PT]) Create(ctx context.Context, e *T) error {
+	if v, ok := any(e).(Versioned); ok {
+		v.SetVersion(1)
+	}
+	if err := r.conn(ctx).Create(e).Error; err != nil {
+		logging.FromContextOr(ctx, r.logger).Errorw("failed to create an entity", "entity", r.name, "err", err)
+		return database.WrapError(err)
+	}
+	return nil
+}
+
+func (r *repository[T, PT]) Update(ctx context.Context, e *T) error {
+	if PT(e).GetID() == 0 {
+		return errors.New("require id to update an entity")
+	}
+	db := r.conn(ctx).Model(e).
+		Select("*").
+		Omit("id", "created_at", "created_by")
+
+	v, versioned := any(e).(Versioned)
+	if !versioned {
+		return r.checkWrite(ctx, e, db.Updates(e), false)
+	}
+	version := v.GetVersion()
+	v.SetVersion(version + 1)
+	if err := r.checkWrite(ctx, e, db.Where("version = ?", version).Updates(e), true); err != nil {
+		v.SetVersion(version)
+		return err
+	}
+	return nil
+}
+
+func (r *repository[T, PT]) Delete(ctx context.Context, e *T) error {
+	if PT(e).GetID() == 0 {
+		return errors.New("require id to delete an entity")
+	}
+	db := r.conn(ctx)
+	v, versioned := any(e).(Versioned)
+	if versioned {
+		db = db.Where("version = ?", v.GetVersion())
+	}
+	return r.checkWrite(ctx, e, db.Delete(e), versioned)
+}
+
+func (r *repository[T, PT]) FindByID(ctx context.Context, id uint, opts ...database.QueryOption) (*T, error) {
+	var result T
+	if err := r.query(ctx, opts...).First(&result, id).Error; err != nil {
+		return nil, r.wrapFindError(ctx, err)
+	}
+	return &result, nil
+}
+
+func (r *repository[T, PT]) FindOne(ctx context.Context, spec Spec, opts ...database.QueryOption) (*T, error) {
+	var (
+		db     = applySorts(applyFilters(r.query(ctx, opts...), spec.Filters), spec.Sorts)
+		result T
+	)
+	if err := db.First(&result).Error; err != nil {
+		return nil, r.wrapFindError(ctx, err)
+	}
+	return &result, nil
+}
+
+func (r *repository[T, PT]) List(ctx context.Context, spec Spec, opts ...database.QueryOption) (*PageResult[T], error) {
+	var (
+		db     = applyFilters(r.query(ctx, opts...).Model(new(T)), spec.Filters)
+		page   = spec.Page
+		result PageResult[T]
+	)
+	if page.Cursor != nil {
+		return r.listByCursor(ctx, db, page)
+	}
+
+	if err := db.Count(&result.Total).Error; err != nil {
+		return nil, r.wrapFindError(ctx, err)
+	}
+	db = applySorts(db, spec.Sorts).Offset(page.Offset)
+	if page.Size > 0 {
+		db = db.Limit(page.Size)
+	}
+	if err := db.Find(&result.Items).Error; err != nil {
+		return nil, r.wrapFindError(ctx, err)
+	}
+	return &result, nil
+}
+
+// listByCursor finds a page of entities after the cursor ordered by id.
+// One more entity than page size is fetched to check the next page exists.
+func (r *repository[T, PT]) listByCursor(ctx context.Context, db *gorm.DB, page Page) (*PageResult[T], error) {
+	var (
+		cursor = page.Cursor
+		id     = clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}
+		result PageResult[T]
+	)
+	if cursor.After != 0 {
+		if cursor.Desc {
+			db = db.Where(clause.Lt{Column: id, Value: cursor.After})
+		} else {
+			db = db.Where(clause.Gt{Column: id, Value: cursor.After})
+		}
+	}
+	db = db.Order(clause.OrderByColumn{Column: id, Desc: cursor.Desc})
+	if page.Size > 0 {
+		db = db.Limit(page.Size + 1)
+	}
+	if err := db.Find(&result.Items).Error; err != nil {
+		return nil, r.wrapFindError(ctx, err)
+	}
+	if page.Size > 0 && len(result.Items) > page.Size {
+		result.Items = result.Items[:page.Size]
+		last := PT(result.Items[page.Size-1])
+		result.NextCursor = &Cursor{After: last.GetID(), Desc: cursor.Desc}
+	}
+	return &result, nil
+}
+
+// checkWrite returns an error of given write result.
+// database.ErrStaleObject is returned if no rows are affected with a version condition.
+func (r *repository[T, PT]) checkWrite(ctx context.Context, e *T, result *gorm.DB, versioned bool) error {
+	if result.Error != nil {
+		logging.FromContextOr(ctx, r.logger).Errorw("failed to write an entity",
+			"entity", r.name, "id", PT(e).GetID(), "err", result.Error)
+		return database.WrapError(result.Error)
+	}
+	if versioned && result.RowsAffected == 0 {
+		return database.ErrStaleObject
+	}
+	return nil
+}
+
+// conn returns a gorm.DB in the context if exists, otherwise the default db.
+func (r *repository[T, PT]) conn(ctx context.Context) *gorm.DB {
+	return database.FromContext(ctx, r.db).WithContext(ctx)
+}
+
+// query returns a gorm.DB to find entities applied given opts.
+func (r *repository[T, PT]) query(ctx context.Context, opts ...database.QueryOption) *gorm.DB {
+	db := r.conn(ctx)
+	if withDeleted(ctx, opts...) {
+		db = db.Unscoped()
+	}
+	return db
+}
+
+func (r *repository[T, PT]) wrapFindError(ctx context.Context, err error) error {
+	if err != gorm.ErrRecordNotFound {
+		logging.FromContextOr(ctx, r.logger).Errorw("failed to find entities", "entity", r.name, "err", err)
+	}
+	return database.WrapError(err)
+}
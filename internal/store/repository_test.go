@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zacscoding/go-rest-template/internal/model"
+	"github.com/zacscoding/go-rest-template/pkg/database"
+)
+
+func (s *StoreSuite) TestRepository_FindByID() {
+	repo, err := NewRepository[model.User]("user", s.db, nil, nil, nil)
+	s.NoError(err)
+	saved := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.NoError(repo.Create(context.TODO(), &saved))
+
+	find, err := repo.FindByID(context.TODO(), saved.ID)
+
+	s.NoError(err)
+	s.Equal(saved.Email, find.Email)
+	s.EqualValues(1, find.Version)
+
+	s.T().Run("Not Found", func(t *testing.T) {
+		find, err := repo.FindByID(context.TODO(), saved.ID+1)
+
+		assert.Nil(t, find)
+		assert.Equal(t, database.ErrRecordNotFound, err)
+	})
+}
+
+func (s *StoreSuite) TestRepository_Update_StaleObject() {
+	repo, err := NewRepository[model.User]("user", s.db, nil, nil, nil)
+	s.NoError(err)
+	saved := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.NoError(repo.Create(context.TODO(), &saved))
+	stale := saved
+	s.NoError(repo.Update(context.TODO(), &saved))
+
+	err = repo.Update(context.TODO(), &stale)
+
+	s.Equal(database.ErrStaleObject, err)
+	s.EqualValues(1, stale.Version)
+}
+
+func (s *StoreSuite) TestRepository_List() {
+	repo, err := NewRepository[model.User]("user", s.db, nil, nil, nil)
+	s.NoError(err)
+	var ids []uint
+	for i := 0; i < 5; i++ {
+		u := model.User{Username: "user", Email: fmt.Sprintf("user%d@email.com", i), Roles: []string{string(model.RoleUser)}}
+		s.NoError(repo.Create(context.TODO(), &u))
+		ids = append(ids, u.ID)
+	}
+	other := model.User{Username: "other", Email: "other@email.com", Roles: []string{string(model.RoleUser)}}
+	s.NoError(repo.Create(context.TODO(), &other))
+
+	s.T().Run("Offset", func(t *testing.T) {
+		page, err := repo.List(context.TODO(), Spec{
+			Filters: []Filter{Eq("username", "user")},
+			Sorts:   []Sort{{Column: "id", Desc: true}},
+			Page:    Page{Size: 2, Offset: 1},
+		})
+
+		assert.NoError(t, err)
+		assert.EqualValues(t, 5, page.Total)
+		assert.Len(t, page.Items, 2)
+		assert.Equal(t, ids[3], page.Items[0].ID)
+		assert.Equal(t, ids[2], page.Items[1].ID)
+		assert.Nil(t, page.NextCursor)
+	})
+
+	s.T().Run("Cursor", func(t *testing.T) {
+		var (
+			spec = Spec{
+				Filters: []Filter{Eq("username", "user")},
+				Page:    Page{Size: 2, Cursor: &Cursor{}},
+			}
+			found []uint
+		)
+		for {
+			page, err := repo.List(context.TODO(), spec)
+			assert.NoError(t, err)
+			for _, u := range page.Items {
+				found = append(found, u.ID)
+			}
+			if page.NextCursor == nil {
+				break
+			}
+			spec.Page.Cursor = page.NextCursor
+		}
+
+		assert.Equal(t, ids, found)
+	})
+
+	s.T().Run("Cursor Desc", func(t *testing.T) {
+		page, err := repo.List(context.TODO(), Spec{
+			Filters: []Filter{In("id", ids)},
+			Page:    Page{Size: 3, Cursor: &Cursor{After: ids[4], Desc: true}},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 3)
+		assert.Equal(t, ids[3], page.Items[0].ID)
+		assert.Equal(t, &Cursor{After: ids[1], Desc: true}, page.NextCursor)
+	})
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Operator is a comparison operator of Filter.
+type Operator string
+
+const (
+	OpEq   Operator = "="
+	OpNe   Operator = "<>"
+	OpGt   Operator = ">"
+	OpGte  Operator = ">="
+	OpLt   Operator = "<"
+	OpLte  Operator = "<="
+	OpIn   Operator = "IN"
+	OpLike Operator = "LIKE"
+)
+
+// Spec is a query specification of Repository.
+type Spec struct {
+	Filters []Filter
+	Sorts   []Sort
+	Page    Page
+}
+
+// Filter is a condition which compares Column with Value by Operator.
+type Filter struct {
+	Column   string
+	Operator Operator
+	Value    interface{}
+}
+
+func Eq(column string, value interface{}) Filter  { return Filter{column, OpEq, value} }
+func Ne(column string, value interface{}) Filter  { return Filter{column, OpNe, value} }
+func Gt(column string, value interface{}) Filter  { return Filter{column, OpGt, value} }
+func Gte(column string, value interface{}) Filter { return Filter{column, OpGte, value} }
+func Lt(column string, value interface{}) Filter  { return Filter{column, OpLt, value} }
+func Lte(column string, value interface{}) Filter { return Filter{column, OpLte, value} }
+func In(column string, values interface{}) Filter { return Filter{column, OpIn, values} }
+func Like(column string, pattern string) Filter   { return Filter{column, OpLike, pattern} }
+
+// Sort orders results by Column.
+type Sort struct {
+	Column string
+	Desc   bool
+}
+
+// Page limits results with offset or keyset pagination.
+type Page struct {
+	// Size is the max number of results. Zero means no limit.
+	Size int
+	// Offset skips the number of results for offset pagination.
+	Offset int
+	// Cursor enables keyset pagination ordered by id if not nil. Offset and Sorts are ignored.
+	Cursor *Cursor
+}
+
+// Cursor is a position of keyset pagination.
+type Cursor struct {
+	// After is the id of the last entity in the previous page. Zero means the first page.
+	After uint
+	// Desc orders by id in descending order if true.
+	Desc bool
+}
+
+// PageResult is a page of List.
+type PageResult[T any] struct {
+	Items []*T
+	// Total is the number of all entities matched with filters. Only counted for offset pagination.
+	Total int64
+	// NextCursor is the cursor of the next page for keyset pagination, nil if there is no more page.
+	NextCursor *Cursor
+}
+
+func (f Filter) expression() clause.Expression {
+	column := clause.Column{Name: f.Column}
+	switch f.Operator {
+	case OpNe:
+		return clause.Neq{Column: column, Value: f.Value}
+	case OpGt:
+		return clause.Gt{Column: column, Value: f.Value}
+	case OpGte:
+		return clause.Gte{Column: column, Value: f.Value}
+	case OpLt:
+		return clause.Lt{Column: column, Value: f.Value}
+	case OpLte:
+		return clause.Lte{Column: column, Value: f.Value}
+	case OpIn:
+		return clause.Expr{SQL: "? IN ?", Vars: []interface{}{column, f.Value}}
+	case OpLike:
+		return clause.Like{Column: column, Value: f.Value}
+	default:
+		return clause.Eq{Column: column, Value: f.Value}
+	}
+}
+
+// applyFilters adds where clauses of given filters to db.
+func applyFilters(db *gorm.DB, filters []Filter) *gorm.DB {
+	for _, f := range filters {
+		db = db.Where(f.expression())
+	}
+	return db
+}
+
+// applySorts adds order by clauses of given sorts to db.
+func applySorts(db *gorm.DB, sorts []Sort) *gorm.DB {
+	for _, s := range sorts {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: s.Column}, Desc: s.Desc})
+	}
+	return db
+}
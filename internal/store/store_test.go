@@ -18,22 +18,35 @@ type StoreSuite struct {
 	dsn     string
 	db      *gorm.DB
 	closeFn database.CloseFunc
+	enc     *database.Encryptor
+	mp      metrics.Provider
 
 	userStore UserStore
 }
 
+// testEncryption is encryption configs required to load configs in tests.
+var testEncryption = map[string]interface{}{
+	"db.encryption.active-key-id":   "test",
+	"db.encryption.keys.test":       "dGVzdC1hcHAtZW5jcnlwdGlvbi1rZXktMzJieXRlcyE=",
+	"db.encryption.blind-index-key": "dGVzdC1hcHAtYmxpbmQtaW5kZXgta2V5",
+}
+
 func TestStoreSuite(t *testing.T) {
 	suite.Run(t, new(StoreSuite))
 }
 
 func (s *StoreSuite) SetupSuite() {
-	conf, err := config.Load("", nil)
+	conf, err := config.Load(config.Files{}, testEncryption)
 	s.NoError(err)
 
 	s.conf = conf
-	mp := metrics.NewProvider(s.conf)
+	s.mp = metrics.NewProvider(s.conf)
 	s.dsn, s.db, s.closeFn = database.NewTestMysqlDB(s.T(), "")
-	s.userStore, _ = NewUserStore(nil, s.db, nil, mp)
+	s.NoError(database.RegisterAuditCallbacks(s.db))
+	s.enc, err = database.NewEncryptor(&s.conf.DB)
+	s.NoError(err)
+	s.NoError(database.RegisterEncryption(s.db, s.enc))
+	s.userStore, _ = NewUserStore(nil, s.db, s.enc, nil, s.mp, nil)
 }
 
 func (s *StoreSuite) BeforeTest(_, _ string) {
@@ -3,62 +3,109 @@ package store
 import (
 	"context"
 	"errors"
-	"fmt"
 
 	"github.com/zacscoding/go-rest-template/internal/config"
 	"github.com/zacscoding/go-rest-template/internal/metrics"
 	"github.com/zacscoding/go-rest-template/internal/model"
 	"github.com/zacscoding/go-rest-template/pkg/cache"
+	"github.com/zacscoding/go-rest-template/pkg/database"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
 )
 
 var _ UserStore = (*userCacheStore)(nil)
 
-const (
-	cacheKeyUserByEmail = "user-by-email"
-)
+// userByEmailNamespace is versioned by the schema of model.User.
+var userByEmailNamespace = cache.NewNamespace("user-by-email", model.User{})
 
 type userCacheStore struct {
+	enc      *database.Encryptor
 	cacher   cache.Cacher
 	mp       metrics.Provider
+	logger   *zap.SugaredLogger
 	delegate UserStore
 }
 
 func newUserCacheStore(_ *config.Config,
+	enc *database.Encryptor,
 	cacher cache.Cacher,
 	mp metrics.Provider,
+	logger *zap.SugaredLogger,
 	delegate UserStore,
 ) (UserStore, error) {
 	if cacher == nil {
 		return nil, errors.New("require cacher")
 	}
 	return &userCacheStore{
+		enc:      enc,
 		cacher:   cacher,
 		mp:       mp,
+		logger:   logger,
 		delegate: delegate,
 	}, nil
 }
 
 func (uc *userCacheStore) Save(ctx context.Context, u *model.User) error {
-	return uc.delegate.Save(ctx, u)
+	// the blind index is of the previous email until the user is saved.
+	prevIndex := u.EmailIndex
+	if err := uc.delegate.Save(ctx, u); err != nil {
+		return err
+	}
+	uc.evict(ctx, u, prevIndex)
+	return nil
+}
+
+func (uc *userCacheStore) Delete(ctx context.Context, u *model.User) error {
+	prevIndex := u.EmailIndex
+	if err := uc.delegate.Delete(ctx, u); err != nil {
+		return err
+	}
+	uc.evict(ctx, u, prevIndex)
+	return nil
 }
 
-func (uc *userCacheStore) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+func (uc *userCacheStore) FindByEmail(ctx context.Context, email string, opts ...database.QueryOption) (*model.User, error) {
+	// deleted users are not cached.
+	if withDeleted(ctx, opts...) {
+		return uc.delegate.FindByEmail(ctx, email, opts...)
+	}
 	var (
 		item     model.User
 		key      = uc.userByEmailKey(email)
 		cacheHit = true
 	)
-	err := uc.cacher.Fetch(ctx, key, &item, func() (interface{}, error) {
+	// caches not found results to protect the database from sign-in attempts with unknown emails.
+	// stale users are not served since they are used to authenticate.
+	err := uc.cacher.Fetch(ctx, key, &item, func(ctx context.Context) (interface{}, error) {
 		cacheHit = false
 		return uc.delegate.FindByEmail(ctx, email)
-	})
+	}, cache.WithNegative(database.ErrRecordNotFound), cache.WithLock())
 	if err != nil {
 		return nil, err
 	}
-	uc.mp.RecordCache(cacheKeyUserByEmail, cacheHit)
+	uc.mp.RecordCache(userByEmailNamespace.Name(), cacheHit)
 	return &item, nil
 }
 
+// evict removes cached items of given u user and its previous email of given prevIndex blind index
+// after the transaction in the context is committed.
+// Evicting after the commit prevents concurrent readers from caching the user before the commit again.
+func (uc *userCacheStore) evict(ctx context.Context, u *model.User, prevIndex string) {
+	keys := []string{uc.userByEmailKey(u.Email)}
+	if prevIndex != "" && prevIndex != uc.enc.BlindIndex(u.Email) {
+		keys = append(keys, userByEmailNamespace.Key(prevIndex))
+	}
+	database.AfterCommit(ctx, func() {
+		for _, key := range keys {
+			if err := uc.cacher.Delete(ctx, key); err != nil {
+				logging.FromContextOr(ctx, uc.logger).Warnw("failed to evict a cached user", "key", key, "err", err)
+			}
+		}
+	})
+}
+
+// userByEmailKey returns a key of given email with its blind index, so emails are not exposed in the cache.
+// Blind indexes are case-insensitive like emails.
 func (uc *userCacheStore) userByEmailKey(email string) string {
-	return fmt.Sprintf("%s.%s", cacheKeyUserByEmail, email)
+	return userByEmailNamespace.Key(uc.enc.BlindIndex(email))
 }
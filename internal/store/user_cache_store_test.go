@@ -7,6 +7,8 @@ import (
 
 	"github.com/stretchr/testify/mock"
 	"github.com/zacscoding/go-rest-template/internal/model"
+	"github.com/zacscoding/go-rest-template/pkg/database"
+	"go.uber.org/zap/zapcore"
 )
 
 func (s *CacheStoreSuite) TestUserStore_Save() {
@@ -93,6 +95,130 @@ func (s *CacheStoreSuite) TestUserStore_FindByEmail_CacheHit() {
 	s.mpMock.AssertCalled(s.T(), "RecordCache", mock.Anything, true)
 }
 
+func (s *CacheStoreSuite) TestUserStore_Save_Evict() {
+	user := model.User{
+		ID:       1,
+		Username: "user1",
+		Email:    "user1@email.com",
+		Password: "userpass",
+		Roles:    []string{string(model.RoleUser)},
+	}
+	disabled := user
+	disabled.Disabled = true
+	s.mpMock.On("RecordCache", mock.Anything, mock.Anything)
+	s.userStoreMock.On("FindByEmail", mock.Anything, mock.Anything).Return(&user, nil).Once()
+	s.userStoreMock.On("FindByEmail", mock.Anything, mock.Anything).Return(&disabled, nil).Once()
+	s.userStoreMock.On("Save", mock.Anything, &disabled).Return(nil)
+	_, err := s.userStore.FindByEmail(context.TODO(), "USER1@email.com")
+	s.NoError(err)
+
+	err = s.userStore.Save(context.TODO(), &disabled)
+
+	s.NoError(err)
+	find, err := s.userStore.FindByEmail(context.TODO(), user.Email)
+	s.NoError(err)
+	s.True(find.Disabled)
+	s.userStoreMock.AssertNumberOfCalls(s.T(), "FindByEmail", 2)
+}
+
+func (s *CacheStoreSuite) TestUserStore_Save_EvictPreviousEmail() {
+	user := model.User{
+		ID:         1,
+		Email:      "user1@email.com",
+		EmailIndex: s.enc.BlindIndex("user1@email.com"),
+		Roles:      []string{string(model.RoleUser)},
+	}
+	s.mpMock.On("RecordCache", mock.Anything, mock.Anything)
+	s.userStoreMock.On("FindByEmail", mock.Anything, "user1@email.com").Return(&user, nil).Once()
+	s.userStoreMock.On("FindByEmail", mock.Anything, "user1@email.com").Return(nil, database.ErrRecordNotFound).Once()
+	s.userStoreMock.On("FindByEmail", mock.Anything, "user2@email.com").Return(nil, database.ErrRecordNotFound).Once()
+	_, err := s.userStore.FindByEmail(context.TODO(), "user1@email.com")
+	s.NoError(err)
+	_, err = s.userStore.FindByEmail(context.TODO(), "user2@email.com")
+	s.ErrorIs(err, database.ErrRecordNotFound)
+	changed := user
+	changed.Email = "user2@email.com"
+	s.userStoreMock.On("Save", mock.Anything, &changed).Run(func(args mock.Arguments) {
+		u := args.Get(1).(*model.User)
+		u.EmailIndex = s.enc.BlindIndex(u.Email)
+	}).Return(nil)
+	s.userStoreMock.On("FindByEmail", mock.Anything, "user2@email.com").Return(&changed, nil).Once()
+
+	s.NoError(s.userStore.Save(context.TODO(), &changed))
+
+	// both of the previous and new emails are evicted.
+	_, err = s.userStore.FindByEmail(context.TODO(), "user1@email.com")
+	s.ErrorIs(err, database.ErrRecordNotFound)
+	find, err := s.userStore.FindByEmail(context.TODO(), "user2@email.com")
+	s.NoError(err)
+	s.Equal("user2@email.com", find.Email)
+}
+
+func (s *CacheStoreSuite) TestUserStore_Save_FailNotEvict() {
+	user := model.User{ID: 1, Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.mpMock.On("RecordCache", mock.Anything, mock.Anything)
+	s.userStoreMock.On("FindByEmail", mock.Anything, user.Email).Return(&user, nil)
+	s.userStoreMock.On("Save", mock.Anything, &user).Return(errors.New("force err"))
+	_, err := s.userStore.FindByEmail(context.TODO(), user.Email)
+	s.NoError(err)
+
+	s.Error(s.userStore.Save(context.TODO(), &user))
+
+	_, err = s.userStore.FindByEmail(context.TODO(), user.Email)
+	s.NoError(err)
+	s.userStoreMock.AssertNumberOfCalls(s.T(), "FindByEmail", 1)
+}
+
+func (s *CacheStoreSuite) TestUserStore_Save_EvictFailLogged() {
+	user := model.User{ID: 1, Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.userStoreMock.On("Save", mock.Anything, &user).Return(nil)
+	s.NoError(s.cacheCloseFn())
+	s.cacheCloseFn = nil
+
+	s.NoError(s.userStore.Save(context.TODO(), &user))
+
+	logs := s.logs.FilterMessage("failed to evict a cached user").All()
+	s.Len(logs, 1)
+	s.Equal(zapcore.WarnLevel, logs[0].Level)
+	s.Equal(s.userStore.userByEmailKey(user.Email), logs[0].ContextMap()["key"])
+}
+
+func (s *CacheStoreSuite) TestUserStore_Delete_Evict() {
+	user := model.User{ID: 1, Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.mpMock.On("RecordCache", mock.Anything, mock.Anything)
+	s.userStoreMock.On("FindByEmail", mock.Anything, user.Email).Return(&user, nil).Once()
+	s.userStoreMock.On("FindByEmail", mock.Anything, user.Email).Return(nil, database.ErrRecordNotFound).Once()
+	s.userStoreMock.On("Delete", mock.Anything, &user).Return(nil)
+	_, err := s.userStore.FindByEmail(context.TODO(), user.Email)
+	s.NoError(err)
+
+	err = s.userStore.Delete(context.TODO(), &user)
+
+	s.NoError(err)
+	_, err = s.userStore.FindByEmail(context.TODO(), user.Email)
+	s.ErrorIs(err, database.ErrRecordNotFound)
+}
+
+func (s *CacheStoreSuite) TestUserStore_FindByEmail_NotFound() {
+	user := model.User{ID: 1, Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.mpMock.On("RecordCache", mock.Anything, mock.Anything)
+	s.userStoreMock.On("FindByEmail", mock.Anything, user.Email).Return(nil, database.ErrRecordNotFound).Once()
+	s.userStoreMock.On("FindByEmail", mock.Anything, user.Email).Return(&user, nil).Once()
+	s.userStoreMock.On("Save", mock.Anything, &user).Return(nil)
+
+	_, err := s.userStore.FindByEmail(context.TODO(), user.Email)
+	s.ErrorIs(err, database.ErrRecordNotFound)
+	_, err = s.userStore.FindByEmail(context.TODO(), user.Email)
+	s.ErrorIs(err, database.ErrRecordNotFound)
+	s.userStoreMock.AssertNumberOfCalls(s.T(), "FindByEmail", 1)
+
+	// the not found result is evicted when the user is saved.
+	s.NoError(s.userStore.Save(context.TODO(), &user))
+	find, err := s.userStore.FindByEmail(context.TODO(), user.Email)
+	s.NoError(err)
+	s.Equal(user.ID, find.ID)
+}
+
 func (s *CacheStoreSuite) checkUser(expected, actual *model.User) {
 	s.Equal(expected.ID, actual.ID)
 	s.Equal(expected.Username, actual.Username)
@@ -2,13 +2,15 @@ package store
 
 import (
 	"context"
+	"time"
 
 	"github.com/zacscoding/go-rest-template/internal/config"
 	"github.com/zacscoding/go-rest-template/internal/metrics"
 	"github.com/zacscoding/go-rest-template/internal/model"
 	"github.com/zacscoding/go-rest-template/pkg/cache"
 	"github.com/zacscoding/go-rest-template/pkg/database"
-	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"github.com/zacscoding/go-rest-template/pkg/utils/authutil"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -17,44 +19,72 @@ var _ UserStore = (*userStore)(nil)
 //go:generate mockery --name UserStore --filename user_store.go
 type UserStore interface {
 	// Save saves a given u user.
+	// An existing user is updated only if its version is matched, otherwise database.ErrStaleObject is returned.
 	Save(ctx context.Context, u *model.User) error
 
+	// Delete soft deletes a given u user if its version is matched, otherwise database.ErrStaleObject is returned.
+	Delete(ctx context.Context, u *model.User) error
+
 	// FindByEmail returns an user with given email if exists, otherwise database.ErrRecordNotFound.
-	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	// Deleted users are excluded unless database.WithDeleted option is given by an admin user.
+	FindByEmail(ctx context.Context, email string, opts ...database.QueryOption) (*model.User, error)
 }
 
-func NewUserStore(conf *config.Config, db *gorm.DB, cacher cache.Cacher, mp metrics.Provider) (UserStore, error) {
+func NewUserStore(conf *config.Config,
+	db *gorm.DB,
+	enc *database.Encryptor,
+	cacher cache.Cacher,
+	mp metrics.Provider,
+	logger *zap.SugaredLogger,
+) (UserStore, error) {
+	// users are cached by id as well as by email.
+	repo, err := NewRepository[model.User]("user", db, cacher, mp, logger)
+	if err != nil {
+		return nil, err
+	}
+	s := &userStore{repo: repo, enc: enc}
 	if cacher == nil {
-		return &userStore{db: db, mp: mp}, nil
+		return s, nil
 	}
-	return newUserCacheStore(conf, cacher, mp, &userStore{db: db, mp: mp})
+	return newUserCacheStore(conf, enc, cacher, mp, logger, s)
 }
 
 type userStore struct {
-	db *gorm.DB
-	mp metrics.Provider
+	repo Repository[model.User]
+	enc  *database.Encryptor
 }
 
 func (s *userStore) Save(ctx context.Context, u *model.User) error {
-	if err := database.FromContext(ctx, s.db).
-		WithContext(ctx).
-		Save(u).Error; err != nil {
-		logging.FromContext(ctx).Errorw("failed to save an user", "err", err)
-		return database.WrapError(err)
+	if u.ID != 0 {
+		return s.repo.Update(ctx, u)
+	}
+	return s.repo.Create(ctx, u)
+}
+
+func (s *userStore) Delete(ctx context.Context, u *model.User) error {
+	// updates instead of deleting to record "updated_by" and increase the version together.
+	u.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	if err := s.repo.Update(ctx, u); err != nil {
+		u.DeletedAt = gorm.DeletedAt{}
+		return err
 	}
 	return nil
 }
 
-func (s *userStore) FindByEmail(ctx context.Context, email string) (*model.User, error) {
-	var (
-		db     = database.FromContext(ctx, s.db).WithContext(ctx)
-		result model.User
-	)
-	if err := db.Where("email = ?", email).First(&result).Error; err != nil {
-		if err != gorm.ErrRecordNotFound {
-			logging.FromContext(ctx).Errorw("failed to find an user by email", "email", email, "err", err)
-		}
-		return nil, database.WrapError(err)
+func (s *userStore) FindByEmail(ctx context.Context, email string, opts ...database.QueryOption) (*model.User, error) {
+	return s.repo.FindOne(ctx, Spec{
+		// emails are encrypted, so find by the blind index.
+		Filters: []Filter{Eq("email_bidx", s.enc.BlindIndex(email))},
+		// the latest one first if the email has been re-registered.
+		Sorts: []Sort{{Column: "id", Desc: true}},
+	}, opts...)
+}
+
+// withDeleted returns true if database.WithDeleted option is given and the current user is an admin.
+func withDeleted(ctx context.Context, opts ...database.QueryOption) bool {
+	if !database.NewQueryOptions(opts...).WithDeleted {
+		return false
 	}
-	return &result, nil
+	u, ok := authutil.CurrentUser(ctx).(*model.User)
+	return ok && u.HasRole(model.RoleAdmin)
 }
@@ -7,7 +7,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/zacscoding/go-rest-template/internal/model"
+	"github.com/zacscoding/go-rest-template/pkg/cache"
 	"github.com/zacscoding/go-rest-template/pkg/database"
+	"github.com/zacscoding/go-rest-template/pkg/utils/authutil"
+	"gorm.io/gorm"
 )
 
 func (s *StoreSuite) TestSave() {
@@ -32,6 +35,71 @@ func (s *StoreSuite) TestSave() {
 	s.WithinDuration(find.UpdatedAt, time.Now(), time.Second)
 }
 
+func (s *StoreSuite) TestSave_Encrypted() {
+	u := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+
+	s.NoError(s.userStore.Save(context.TODO(), &u))
+
+	var row struct {
+		Email     string
+		EmailBidx string
+	}
+	s.NoError(s.db.Table("users").Select("email, email_bidx").Where("id = ?", u.ID).Scan(&row).Error)
+	s.NotContains(row.Email, u.Email)
+	s.Equal(s.enc.BlindIndex(u.Email), row.EmailBidx)
+	find, err := s.userStore.FindByEmail(context.TODO(), "USER1@email.com")
+	s.NoError(err)
+	s.Equal(u.Email, find.Email)
+}
+
+func (s *StoreSuite) TestFindByEmail_AfterReload() {
+	defer func() { s.NoError(database.RegisterEncryption(s.db, s.enc)) }()
+	conf := s.conf.DB
+	conf.Encryption.ActiveKeyID = "rotated"
+	conf.Encryption.Keys = map[string]string{
+		"test":    s.conf.DB.Encryption.Keys["test"],
+		"rotated": "cm90YXRlZC1hcHAtZW5jcnlwdGlvbi1rZXktMzJieSE=",
+	}
+	s.NoError(database.Reload(s.db, &conf))
+
+	u := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.NoError(s.userStore.Save(context.TODO(), &u))
+
+	find, err := s.userStore.FindByEmail(context.TODO(), u.Email)
+	s.NoError(err)
+	s.Equal(u.ID, find.ID)
+	conf.Encryption.BlindIndexKey = "cm90YXRlZC1hcHAtYmxpbmQtaW5kZXgta2V5"
+	s.ErrorContains(database.Reload(s.db, &conf), "the blind index key can not be changed")
+}
+
+func (s *StoreSuite) TestSave_EvictAfterCommit() {
+	cacher, err := cache.NewCacher(&cache.Config{Enabled: true, Type: "memory", TTL: time.Minute}, nil, nil)
+	s.NoError(err)
+	userStore, err := NewUserStore(nil, s.db, s.enc, cacher, s.mp, nil)
+	s.NoError(err)
+	u := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.NoError(userStore.Save(context.TODO(), &u))
+	_, err = userStore.FindByEmail(context.TODO(), u.Email)
+	s.NoError(err)
+
+	err = database.RunInTx(context.TODO(), s.db, nil, func(txdb *gorm.DB) error {
+		u.Disabled = true
+		if err := userStore.Save(database.WithContext(context.TODO(), txdb), &u); err != nil {
+			return err
+		}
+		// not evicted until committed.
+		find, err := userStore.FindByEmail(context.TODO(), u.Email)
+		s.NoError(err)
+		s.False(find.Disabled)
+		return nil
+	})
+
+	s.NoError(err)
+	find, err := userStore.FindByEmail(context.TODO(), u.Email)
+	s.NoError(err)
+	s.True(find.Disabled)
+}
+
 func (s *StoreSuite) TestSave_Fail() {
 	saved := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
 	s.NoError(s.userStore.Save(context.TODO(), &saved))
@@ -49,6 +117,109 @@ func (s *StoreSuite) TestSave_Fail() {
 	})
 }
 
+func (s *StoreSuite) TestSave_Update() {
+	saved := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.NoError(s.userStore.Save(context.TODO(), &saved))
+	s.EqualValues(1, saved.Version)
+
+	saved.Username = "updated"
+	err := s.userStore.Save(context.TODO(), &saved)
+
+	s.NoError(err)
+	s.EqualValues(2, saved.Version)
+	find, err := s.userStore.FindByEmail(context.TODO(), saved.Email)
+	s.NoError(err)
+	s.Equal("updated", find.Username)
+	s.Equal(saved.Version, find.Version)
+	s.Contains(find.Roles, string(model.RoleUser))
+}
+
+func (s *StoreSuite) TestSave_StaleObject() {
+	saved := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.NoError(s.userStore.Save(context.TODO(), &saved))
+	stale := saved
+	saved.Username = "updated1"
+	s.NoError(s.userStore.Save(context.TODO(), &saved))
+
+	stale.Username = "updated2"
+	err := s.userStore.Save(context.TODO(), &stale)
+
+	s.Error(err)
+	s.Equal(database.ErrStaleObject, err)
+	s.EqualValues(1, stale.Version)
+	find, err := s.userStore.FindByEmail(context.TODO(), saved.Email)
+	s.NoError(err)
+	s.Equal("updated1", find.Username)
+}
+
+func (s *StoreSuite) TestSave_Audit() {
+	creator := &model.User{ID: 100}
+	u := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.NoError(s.userStore.Save(authutil.WithUserContext(context.TODO(), creator), &u))
+
+	updater := &model.User{ID: 200}
+	u.Username = "updated"
+	s.NoError(s.userStore.Save(authutil.WithUserContext(context.TODO(), updater), &u))
+
+	find, err := s.userStore.FindByEmail(context.TODO(), u.Email)
+	s.NoError(err)
+	s.EqualValues(creator.ID, find.CreatedBy)
+	s.EqualValues(updater.ID, find.UpdatedBy)
+}
+
+func (s *StoreSuite) TestDelete() {
+	saved := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.NoError(s.userStore.Save(context.TODO(), &saved))
+
+	err := s.userStore.Delete(context.TODO(), &saved)
+
+	s.NoError(err)
+	s.True(saved.DeletedAt.Valid)
+	find, err := s.userStore.FindByEmail(context.TODO(), saved.Email)
+	s.Nil(find)
+	s.Equal(database.ErrRecordNotFound, err)
+
+	s.T().Run("Re-Register", func(t *testing.T) {
+		u := model.User{Email: saved.Email, Roles: []string{string(model.RoleUser)}}
+
+		assert.NoError(t, s.userStore.Save(context.TODO(), &u))
+		assert.NotEqual(t, saved.ID, u.ID)
+	})
+
+	s.T().Run("Stale Object", func(t *testing.T) {
+		err := s.userStore.Delete(context.TODO(), &saved)
+
+		assert.Equal(t, database.ErrStaleObject, err)
+	})
+}
+
+func (s *StoreSuite) TestFindByEmail_WithDeleted() {
+	saved := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
+	s.NoError(s.userStore.Save(context.TODO(), &saved))
+	s.NoError(s.userStore.Delete(context.TODO(), &saved))
+
+	s.T().Run("Admin", func(t *testing.T) {
+		admin := &model.User{ID: 100, RolesMap: map[model.Role]struct{}{model.RoleAdmin: {}}}
+		ctx := authutil.WithUserContext(context.TODO(), admin)
+
+		find, err := s.userStore.FindByEmail(ctx, saved.Email, database.WithDeleted())
+
+		assert.NoError(t, err)
+		assert.Equal(t, saved.ID, find.ID)
+		assert.True(t, find.DeletedAt.Valid)
+	})
+
+	s.T().Run("Not Admin", func(t *testing.T) {
+		user := &model.User{ID: 100, RolesMap: map[model.Role]struct{}{model.RoleUser: {}}}
+		ctx := authutil.WithUserContext(context.TODO(), user)
+
+		find, err := s.userStore.FindByEmail(ctx, saved.Email, database.WithDeleted())
+
+		assert.Nil(t, find)
+		assert.Equal(t, database.ErrRecordNotFound, err)
+	})
+}
+
 func (s *StoreSuite) TestFindByEmail() {
 	saved := model.User{Email: "user1@email.com", Roles: []string{string(model.RoleUser)}}
 	s.NoError(s.userStore.Save(context.TODO(), &saved))
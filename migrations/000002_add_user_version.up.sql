@@ -0,0 +1,2 @@
+ALTER TABLE `users`
+    ADD COLUMN `version` BIGINT NOT NULL DEFAULT 1 COMMENT '버전' AFTER `disabled`;
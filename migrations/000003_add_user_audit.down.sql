@@ -0,0 +1,9 @@
+ALTER TABLE `users`
+    DROP INDEX `ix_deleted_at`,
+    DROP INDEX `ix_email`,
+    DROP INDEX `ux_active_email`,
+    DROP COLUMN `active_email`,
+    DROP COLUMN `deleted_at`,
+    DROP COLUMN `updated_by`,
+    DROP COLUMN `created_by`,
+    ADD UNIQUE INDEX `ux_email` (`email`);
@@ -0,0 +1,9 @@
+ALTER TABLE `users`
+    ADD COLUMN `created_by`   BIGINT       NOT NULL DEFAULT 0 COMMENT '생성자' AFTER `updated_at`,
+    ADD COLUMN `updated_by`   BIGINT       NOT NULL DEFAULT 0 COMMENT '수정자' AFTER `created_by`,
+    ADD COLUMN `deleted_at`   DATETIME     NULL COMMENT '삭제일' AFTER `updated_by`,
+    ADD COLUMN `active_email` VARCHAR(255) AS (IF(`deleted_at` IS NULL, `email`, NULL)) STORED,
+    DROP INDEX `ux_email`,
+    ADD UNIQUE INDEX `ux_active_email` (`active_email`),
+    ADD INDEX `ix_email` (`email`),
+    ADD INDEX `ix_deleted_at` (`deleted_at`);
@@ -0,0 +1,6 @@
+-- email columns are not shrunk back to VARCHAR(255) since rows keep encrypted emails.
+ALTER TABLE `users`
+    DROP INDEX `ix_email_bidx`,
+    DROP INDEX `ux_active_email_bidx`,
+    DROP COLUMN `active_email_bidx`,
+    DROP COLUMN `email_bidx`;
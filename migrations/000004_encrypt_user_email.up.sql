@@ -0,0 +1,7 @@
+ALTER TABLE `users`
+    MODIFY COLUMN `email` VARCHAR(512) NOT NULL COMMENT '암호화된 이메일',
+    MODIFY COLUMN `active_email` VARCHAR(512) AS (IF(`deleted_at` IS NULL, `email`, NULL)) STORED,
+    ADD COLUMN `email_bidx` VARCHAR(64) NULL COMMENT '이메일 blind index' AFTER `email`,
+    ADD COLUMN `active_email_bidx` VARCHAR(64) AS (IF(`deleted_at` IS NULL, `email_bidx`, NULL)) STORED,
+    ADD UNIQUE INDEX `ux_active_email_bidx` (`active_email_bidx`),
+    ADD INDEX `ix_email_bidx` (`email_bidx`);
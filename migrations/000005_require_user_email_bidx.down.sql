@@ -0,0 +1,5 @@
+ALTER TABLE `users`
+    MODIFY COLUMN `email_bidx` VARCHAR(64) NULL COMMENT '이메일 blind index',
+    ADD COLUMN `active_email` VARCHAR(512) AS (IF(`deleted_at` IS NULL, `email`, NULL)) STORED,
+    ADD UNIQUE INDEX `ux_active_email` (`active_email`),
+    ADD INDEX `ix_email` (`email`);
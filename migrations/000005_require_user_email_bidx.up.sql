@@ -0,0 +1,5 @@
+ALTER TABLE `users`
+    DROP INDEX `ux_active_email`,
+    DROP INDEX `ix_email`,
+    DROP COLUMN `active_email`,
+    MODIFY COLUMN `email_bidx` VARCHAR(64) NOT NULL COMMENT '이메일 blind index';
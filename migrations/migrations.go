@@ -0,0 +1,20 @@
+// Package migrations embeds database migration files into the binary.
+package migrations
+
+import (
+	"embed"
+
+	"github.com/zacscoding/go-rest-template/internal/model"
+	"github.com/zacscoding/go-rest-template/pkg/database"
+)
+
+// FS contains "*.sql" migration files of golang-migrate.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// Backfills fill blind indexes of existing rows before migrations requiring them are applied.
+var Backfills = []database.Backfill{
+	// 000004 adds users.email_bidx and 000005 makes it NOT NULL.
+	{Version: 4, Model: &model.User{}},
+}
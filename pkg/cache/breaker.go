@@ -0,0 +1,359 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+var ErrCircuitOpen = errors.New("cache circuit is open")
+
+// CircuitState is a state of a circuit breaker.
+type CircuitState int32
+
+const (
+	// CircuitClosed passes all requests to the cache.
+	CircuitClosed CircuitState = iota
+	// CircuitHalfOpen passes limited requests to the cache to probe it is recovered.
+	CircuitHalfOpen
+	// CircuitOpen bypasses the cache.
+	CircuitOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half-open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitReporter reports a CircuitState of a Cacher.
+type CircuitReporter interface {
+	CircuitState() CircuitState
+}
+
+type BreakerConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// FailureThreshold is a number of consecutive failures to open the circuit.
+	FailureThreshold int `json:"failure-threshold" yaml:"failure-threshold"`
+	// OpenTimeout is a duration to bypass the cache before probing.
+	OpenTimeout time.Duration `json:"open-timeout" yaml:"open-timeout"`
+	// HalfOpenRequests is a number of successful probes to close the circuit.
+	HalfOpenRequests int `json:"half-open-requests" yaml:"half-open-requests"`
+}
+
+var (
+	_ Cacher          = (*breakerCacher)(nil)
+	_ StatsReporter   = (*breakerCacher)(nil)
+	_ CircuitReporter = (*breakerCacher)(nil)
+)
+
+// breakerCacher is a Cacher failing open with a circuit breaker.
+// Failed or bypassed reads are handled as cache misses and Fetch calls FetchFunc directly,
+// so outage of the cache degrades performance instead of availability.
+// Writes return an error while the circuit is open, but invalidations are always attempted
+// since circuits are per process and a dropped invalidation leaves stale items served to other processes.
+// Operations rejected while the circuit is open are recorded with "circuit_open" error type.
+type breakerCacher struct {
+	delegate Cacher
+	circuit  *circuit
+	codec    Codec
+	recorder Recorder
+}
+
+func newBreakerCacher(conf *BreakerConfig,
+	codec Codec,
+	delegate Cacher,
+	recorder Recorder,
+	logger *zap.SugaredLogger,
+) *breakerCacher {
+	if recorder == nil {
+		recorder = noopRecorder{}
+	}
+	return &breakerCacher{
+		delegate: delegate,
+		circuit:  newCircuit(conf, logger),
+		codec:    codec,
+		recorder: recorder,
+	}
+}
+
+func (b *breakerCacher) Fetch(ctx context.Context, key string, value interface{}, fetchFunc FetchFunc, opts ...Option) error {
+	if fetchFunc == nil || !b.circuit.allow() {
+		if fetchFunc == nil {
+			return b.Get(ctx, key, value)
+		}
+		b.reject("get", key)
+		return b.fetchDirect(ctx, value, fetchFunc)
+	}
+	var (
+		fetched  bool
+		fetchVal interface{}
+		fetchErr error
+	)
+	err := b.delegate.Fetch(ctx, key, value, func(ctx context.Context) (interface{}, error) {
+		fetched = true
+		fetchVal, fetchErr = fetchFunc(ctx)
+		return fetchVal, fetchErr
+	}, opts...)
+	// errors of fetchFunc passed through and the done ctx of the caller are not failures of the cache.
+	if err == nil || (ctx.Err() != nil && err == ctx.Err()) || (fetched && err == fetchErr) {
+		b.record(nil, nil)
+		return err
+	}
+	if !b.record(err, newOptions(opts...)) {
+		return err
+	}
+	if fetched {
+		if fetchErr != nil {
+			return fetchErr
+		}
+		return b.copyValue(fetchVal, value)
+	}
+	return b.fetchDirect(ctx, value, fetchFunc)
+}
+
+func (b *breakerCacher) Get(ctx context.Context, key string, value interface{}) error {
+	if !b.circuit.allow() {
+		b.reject("get", key)
+		return ErrCacheMiss
+	}
+	err := b.delegate.Get(ctx, key, value)
+	if b.record(err, nil) {
+		return ErrCacheMiss
+	}
+	return err
+}
+
+func (b *breakerCacher) Set(ctx context.Context, key string, value interface{}, opts ...Option) error {
+	if !b.circuit.allow() {
+		b.reject("set", key)
+		return ErrCircuitOpen
+	}
+	err := b.delegate.Set(ctx, key, value, opts...)
+	b.record(err, nil)
+	return err
+}
+
+func (b *breakerCacher) MGet(ctx context.Context, keys []string, values []interface{}) ([]string, error) {
+	if !b.circuit.allow() {
+		if len(keys) > 0 {
+			b.reject("mget", keys[0])
+		}
+		return keys, nil
+	}
+	missing, err := b.delegate.MGet(ctx, keys, values)
+	if b.record(err, nil) {
+		return keys, nil
+	}
+	return missing, err
+}
+
+func (b *breakerCacher) MSet(ctx context.Context, items map[string]interface{}, opts ...Option) error {
+	if !b.circuit.allow() {
+		for key := range items {
+			b.reject("set", key)
+			break
+		}
+		return ErrCircuitOpen
+	}
+	err := b.delegate.MSet(ctx, items, opts...)
+	b.record(err, nil)
+	return err
+}
+
+func (b *breakerCacher) Exists(ctx context.Context, key string) (bool, error) {
+	if !b.circuit.allow() {
+		b.reject("get", key)
+		return false, nil
+	}
+	ok, err := b.delegate.Exists(ctx, key)
+	if b.record(err, nil) {
+		return false, nil
+	}
+	return ok, err
+}
+
+func (b *breakerCacher) Delete(ctx context.Context, key string) error {
+	err := b.delegate.Delete(ctx, key)
+	b.record(err, nil)
+	return err
+}
+
+func (b *breakerCacher) InvalidateTags(ctx context.Context, tags ...string) error {
+	err := b.delegate.InvalidateTags(ctx, tags...)
+	b.record(err, nil)
+	return err
+}
+
+func (b *breakerCacher) CircuitState() CircuitState {
+	return b.circuit.currentState()
+}
+
+func (b *breakerCacher) Stats() *Stats {
+	if reporter, ok := b.delegate.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return &Stats{}
+}
+
+func (b *breakerCacher) Close() error {
+	return b.delegate.Close()
+}
+
+func (b *breakerCacher) redisClient() redis.UniversalClient {
+	return redisClientOf(b.delegate)
+}
+
+// record records a result of a cache operation with given err and returns true if the err is a failure of the cache.
+func (b *breakerCacher) record(err error, o *options) bool {
+	if !isCacheFailure(err, o) {
+		b.circuit.success()
+		return false
+	}
+	b.circuit.failure()
+	return true
+}
+
+// reject records given op on given key rejected by the open circuit.
+// Bulk operations are recorded with the group of the first key like recordingStore.
+func (b *breakerCacher) reject(op, key string) {
+	b.recorder.RecordCacheOperation(op, keyGroup("", key), 0, errorType(ErrCircuitOpen))
+}
+
+func (b *breakerCacher) fetchDirect(ctx context.Context, value interface{}, fetchFunc FetchFunc) error {
+	v, err := fetchFunc(ctx)
+	if err != nil {
+		return err
+	}
+	return b.copyValue(v, value)
+}
+
+// copyValue copies given v to value with the same encoding of cached items.
+func (b *breakerCacher) copyValue(v interface{}, value interface{}) error {
+	data, err := b.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := b.codec.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	return nil
+}
+
+// isCacheFailure returns true if given err is caused by the cache, not by callers.
+func isCacheFailure(err error, o *options) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrCacheMiss),
+		errors.Is(err, ErrInvalidKey),
+		errors.Is(err, ErrInvalidValue),
+		errors.Is(err, context.Canceled):
+		return false
+	case o != nil && o.negativeErr != nil && errors.Is(err, o.negativeErr):
+		return false
+	}
+	return true
+}
+
+// circuit is a state machine of a circuit breaker.
+type circuit struct {
+	mu        sync.Mutex
+	conf      BreakerConfig
+	state     CircuitState
+	failures  int
+	probes    int
+	successes int
+	openedAt  time.Time
+	now       func() time.Time
+	logger    *zap.SugaredLogger
+}
+
+func newCircuit(conf *BreakerConfig, logger *zap.SugaredLogger) *circuit {
+	c := circuit{conf: *conf, now: time.Now, logger: logger}
+	if c.conf.FailureThreshold <= 0 {
+		c.conf.FailureThreshold = 1
+	}
+	if c.conf.HalfOpenRequests <= 0 {
+		c.conf.HalfOpenRequests = 1
+	}
+	return &c
+}
+
+// allow returns true if a request can be passed to the cache.
+func (c *circuit) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitClosed:
+		return true
+	case CircuitOpen:
+		if c.now().Sub(c.openedAt) < c.conf.OpenTimeout {
+			return false
+		}
+		c.transit(CircuitHalfOpen)
+	}
+	if c.probes >= c.conf.HalfOpenRequests {
+		return false
+	}
+	c.probes++
+	return true
+}
+
+func (c *circuit) success() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitClosed:
+		c.failures = 0
+	case CircuitHalfOpen:
+		c.successes++
+		if c.successes >= c.conf.HalfOpenRequests {
+			c.transit(CircuitClosed)
+		}
+	}
+}
+
+func (c *circuit) failure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitClosed:
+		c.failures++
+		if c.failures >= c.conf.FailureThreshold {
+			c.transit(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		c.transit(CircuitOpen)
+	}
+}
+
+func (c *circuit) currentState() CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// transit changes the state to given to. It is called while holding the lock.
+func (c *circuit) transit(to CircuitState) {
+	loggerOf(context.Background(), c.logger).Warnw("cache circuit state changed", "from", c.state.String(), "to", to.String())
+	c.state = to
+	c.failures, c.probes, c.successes = 0, 0, 0
+	if to == CircuitOpen {
+		c.openedAt = c.now()
+	}
+}
@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap/zapcore"
+)
+
+func newTestBreakerCacher(t *testing.T) (*breakerCacher, *miniredis.Miniredis) {
+	s := miniredis.RunT(t)
+	cacher, err := newRedisCacher(&Config{
+		TTL:         time.Minute,
+		NegativeTTL: 5 * time.Second,
+		Redis:       RedisConfig{Endpoints: []string{s.Addr()}},
+	}, nil, nil)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = cacher.Close() })
+	codec, err := NewCodec(CodecMsgpack)
+	assert.NoError(t, err)
+	return newBreakerCacher(&BreakerConfig{
+		Enabled:          true,
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		HalfOpenRequests: 2,
+	}, codec, cacher, nil, nil), s
+}
+
+func TestBreaker_FailOpen(t *testing.T) {
+	b, s := newTestBreakerCacher(t)
+	fetchFunc := func(context.Context) (interface{}, error) {
+		return "value1", nil
+	}
+	s.SetError("force error")
+
+	// failures are bypassed while closed
+	var find string
+	for i := 0; i < 2; i++ {
+		find = ""
+		assert.NoError(t, b.Fetch(context.TODO(), "key1", &find, fetchFunc))
+		assert.Equal(t, "value1", find)
+	}
+	assert.Equal(t, CircuitOpen, b.CircuitState())
+
+	// bypass the cache while open
+	s.SetError("")
+	find = ""
+	assert.NoError(t, b.Fetch(context.TODO(), "key1", &find, fetchFunc))
+	assert.Equal(t, "value1", find)
+	assert.False(t, s.Exists("key1"))
+	assert.ErrorIs(t, b.Get(context.TODO(), "key1", &find), ErrCacheMiss)
+	ok, err := b.Exists(context.TODO(), "key1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	missing, err := b.MGet(context.TODO(), []string{"key1", "key2"}, []interface{}{new(string), new(string)})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"key1", "key2"}, missing)
+	assert.ErrorIs(t, b.Set(context.TODO(), "key1", "value1"), ErrCircuitOpen)
+
+	// invalidations are not bypassed while open
+	assert.NoError(t, s.Set("key2", "value2"))
+	assert.NoError(t, b.Delete(context.TODO(), "key2"))
+	assert.False(t, s.Exists("key2"))
+	assert.NoError(t, b.InvalidateTags(context.TODO(), "tag1"))
+	assert.Equal(t, CircuitOpen, b.CircuitState())
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	b, s := newTestBreakerCacher(t)
+	now := time.Now()
+	b.circuit.now = func() time.Time { return now }
+	s.SetError("force error")
+	for i := 0; i < 2; i++ {
+		assert.ErrorIs(t, b.Get(context.TODO(), "key1", new(string)), ErrCacheMiss)
+	}
+	assert.Equal(t, CircuitOpen, b.CircuitState())
+
+	// re-open if a probe is failed
+	now = now.Add(time.Minute)
+	assert.ErrorIs(t, b.Get(context.TODO(), "key1", new(string)), ErrCacheMiss)
+	assert.Equal(t, CircuitOpen, b.CircuitState())
+
+	// close if probes are succeeded
+	s.SetError("")
+	now = now.Add(time.Minute)
+	assert.NoError(t, b.Set(context.TODO(), "key1", "value1"))
+	assert.Equal(t, CircuitHalfOpen, b.CircuitState())
+	var find string
+	assert.NoError(t, b.Get(context.TODO(), "key1", &find))
+	assert.Equal(t, "value1", find)
+	assert.Equal(t, CircuitClosed, b.CircuitState())
+}
+
+func TestBreaker_HalfOpen_Fetch(t *testing.T) {
+	b, s := newTestBreakerCacher(t)
+	now := time.Now()
+	b.circuit.now = func() time.Time { return now }
+	fetchFunc := func(context.Context) (interface{}, error) {
+		return "value1", nil
+	}
+	s.SetError("force error")
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, b.Fetch(context.TODO(), "key1", new(string), fetchFunc))
+	}
+	assert.Equal(t, CircuitOpen, b.CircuitState())
+
+	// close if probes of Fetch are succeeded
+	s.SetError("")
+	now = now.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		var find string
+		assert.NoError(t, b.Fetch(context.TODO(), "key1", &find, fetchFunc))
+		assert.Equal(t, "value1", find)
+	}
+	assert.Equal(t, CircuitClosed, b.CircuitState())
+	assert.True(t, s.Exists("key1"))
+}
+
+func TestBreaker_FetchResetFailures(t *testing.T) {
+	b, s := newTestBreakerCacher(t)
+	fetchFunc := func(context.Context) (interface{}, error) {
+		return "value1", nil
+	}
+
+	// failures separated by a successful Fetch are not consecutive
+	for i := 0; i < 3; i++ {
+		s.SetError("force error")
+		assert.NoError(t, b.Fetch(context.TODO(), "key1", new(string), fetchFunc))
+		s.SetError("")
+		assert.NoError(t, b.Fetch(context.TODO(), "key1", new(string), fetchFunc))
+	}
+	assert.Equal(t, CircuitClosed, b.CircuitState())
+}
+
+func TestBreaker_NotFailure(t *testing.T) {
+	b, _ := newTestBreakerCacher(t)
+	errFetch := errors.New("fetch error")
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		var find string
+		assert.ErrorIs(t, b.Get(context.TODO(), "missing", &find), ErrCacheMiss)
+		assert.ErrorIs(t, b.Fetch(context.TODO(), "fetch-err", &find, func(context.Context) (interface{}, error) {
+			return nil, errFetch
+		}), errFetch)
+		assert.ErrorIs(t, b.Fetch(context.TODO(), "negative", &find, func(context.Context) (interface{}, error) {
+			return nil, errTestNotFound
+		}, WithNegative(errTestNotFound)), errTestNotFound)
+		assert.ErrorIs(t, b.Get(context.TODO(), "", &find), ErrInvalidKey)
+		// deadlines of callers are not failures of the cache.
+		assert.ErrorIs(t, b.Fetch(expired, "expired", &find, func(context.Context) (interface{}, error) {
+			return "value1", nil
+		}), context.DeadlineExceeded)
+	}
+	assert.Equal(t, CircuitClosed, b.CircuitState())
+}
+
+func TestCircuit_Logs(t *testing.T) {
+	logger, logs := logging.NewObservedLogger(zapcore.InfoLevel)
+	c := newCircuit(&BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1}, logger)
+
+	c.failure()
+
+	assert.Equal(t, CircuitOpen, c.currentState())
+	entries := logs.FilterMessage("cache circuit state changed").All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "cache", entries[0].LoggerName)
+	assert.Equal(t, map[string]interface{}{"from": "closed", "to": "open"}, entries[0].ContextMap())
+}
+
+func TestBreaker_RecordRejections(t *testing.T) {
+	b, s := newTestBreakerCacher(t)
+	recorder := newTestRecorder()
+	b.recorder = recorder
+	s.SetError("force error")
+	for i := 0; i < 2; i++ {
+		assert.ErrorIs(t, b.Get(context.TODO(), "user.1", new(string)), ErrCacheMiss)
+	}
+	assert.Equal(t, CircuitOpen, b.CircuitState())
+
+	assert.ErrorIs(t, b.Get(context.TODO(), "user.1", new(string)), ErrCacheMiss)
+	assert.NoError(t, b.Fetch(context.TODO(), "user.2", new(string), func(context.Context) (interface{}, error) {
+		return "value2", nil
+	}))
+	_, err := b.MGet(context.TODO(), []string{"user.1", "user.2"}, []interface{}{new(string), new(string)})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, b.Set(context.TODO(), "user.1", "value1"), ErrCircuitOpen)
+	// invalidations are not rejected.
+	s.SetError("")
+	assert.NoError(t, b.Delete(context.TODO(), "user.1"))
+
+	assert.Equal(t, []string{
+		"get:user:circuit_open",
+		"get:user:circuit_open",
+		"mget:user:circuit_open",
+		"set:user:circuit_open",
+	}, recorder.operations)
+}
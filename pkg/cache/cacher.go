@@ -6,24 +6,49 @@ import (
 	"fmt"
 	"io"
 	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/hashicorp/go-multierror"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
 )
 
+// loggerName is a name of loggers in this package whose level can be changed by logging.Levels.
+const loggerName = "cache"
+
 var (
 	ErrCacheMiss    = errors.New("key is missing")
 	ErrInvalidKey   = errors.New("key is invalid")
 	ErrInvalidValue = errors.New("value type is invalid")
 )
 
-type FetchFunc func() (interface{}, error)
+// FetchFunc loads an item missing in the cache. The ctx is detached from a caller of Fetch
+// and bounded by Config.LoadTimeout since the item is shared by concurrent callers of the same key.
+type FetchFunc func(ctx context.Context) (interface{}, error)
 
 type CloseFn func() error
 
 type Config struct {
-	Enabled bool          `json:"enabled" yaml:"enabled"`
-	Prefix  string        `json:"prefix" yaml:"prefix"`
-	Type    string        `json:"type" yaml:"type"`
-	TTL     time.Duration `json:"ttl" yaml:"ttl"`
-	Redis   RedisConfig   `json:"redis" yaml:"redis"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Prefix  string `json:"prefix" yaml:"prefix"`
+	Type    string `json:"type" yaml:"type"`
+	// Codec is a name of the Codec to encode values. One of msgpack, json and gob.
+	Codec string        `json:"codec" yaml:"codec"`
+	TTL   time.Duration `json:"ttl" yaml:"ttl"`
+	// TTLJitter is a max ratio of TTL randomly added to each item to prevent synchronized expiry.
+	TTLJitter float64 `json:"ttl-jitter" yaml:"ttl-jitter"`
+	// NegativeTTL is a TTL of not found results cached by WithNegative option.
+	NegativeTTL time.Duration `json:"negative-ttl" yaml:"negative-ttl"`
+	// LockTTL is a max duration of a lock acquired to fetch an item by WithLock option.
+	LockTTL time.Duration `json:"lock-ttl" yaml:"lock-ttl"`
+	// LoadTimeout is a max duration of FetchFunc and caching its result. Unlimited if zero.
+	LoadTimeout time.Duration `json:"load-timeout" yaml:"load-timeout"`
+	Redis       RedisConfig   `json:"redis" yaml:"redis"`
+	Memory      MemoryConfig  `json:"memory" yaml:"memory"`
+	Local       LocalConfig   `json:"local" yaml:"local"`
+	// Breaker fails open to FetchFunc while the cache is unavailable.
+	Breaker BreakerConfig `json:"breaker" yaml:"breaker"`
+	Locker  LockerConfig  `json:"locker" yaml:"locker"`
 }
 
 type RedisConfig struct {
@@ -36,6 +61,147 @@ type RedisConfig struct {
 	PoolTimeout  time.Duration `json:"pool-timeout" yaml:"pool-timeout"`
 	MaxConnAge   time.Duration `json:"max-conn-age" yaml:"max-conn-age"`
 	IdleTimeout  time.Duration `json:"idle-timeout" yaml:"idle-timeout"`
+	// Username is an ACL username. Password is used with legacy AUTH command if empty.
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password" secret:"true"`
+	// DB is a database index which must be zero on cluster.
+	DB       int            `json:"db" yaml:"db"`
+	TLS      RedisTLSConfig `json:"tls" yaml:"tls"`
+	Sentinel struct {
+		// MasterName enables failover with sentinels of the Endpoints if not empty.
+		MasterName string `json:"master-name" yaml:"master-name"`
+		Username   string `json:"username" yaml:"username"`
+		Password   string `json:"password" yaml:"password" secret:"true"`
+	} `json:"sentinel" yaml:"sentinel"`
+}
+
+type RedisTLSConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// ServerName is used to verify the hostname of server certificates. Defaults to the host of endpoints.
+	ServerName         string `json:"server-name" yaml:"server-name"`
+	InsecureSkipVerify bool   `json:"insecure-skip-verify" yaml:"insecure-skip-verify"`
+	// CAFile is a PEM file of CA certificates. System CA certificates are used if empty.
+	CAFile string `json:"ca-file" yaml:"ca-file"`
+	// CertFile and KeyFile are PEM files of a client certificate for mutual TLS.
+	CertFile string `json:"cert-file" yaml:"cert-file"`
+	KeyFile  string `json:"key-file" yaml:"key-file"`
+}
+
+// Validate returns errors of invalid configs.
+func (c *RedisConfig) Validate() error {
+	var result *multierror.Error
+	if len(c.Endpoints) == 0 {
+		result = multierror.Append(result, errors.New("require at least one endpoint"))
+	}
+	if c.DB < 0 {
+		result = multierror.Append(result, fmt.Errorf("invalid db: %d", c.DB))
+	}
+	if c.Cluster && c.DB != 0 {
+		result = multierror.Append(result, errors.New("db must be zero on cluster"))
+	}
+	if c.Cluster && c.Sentinel.MasterName != "" {
+		result = multierror.Append(result, errors.New("sentinel is not supported on cluster"))
+	}
+	if c.Password == "" && c.Username != "" {
+		result = multierror.Append(result, errors.New("require password with username"))
+	}
+	if !c.TLS.Enabled && (c.TLS.CAFile != "" || c.TLS.CertFile != "" || c.TLS.KeyFile != "") {
+		result = multierror.Append(result, errors.New("tls files are given but tls is disabled"))
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		result = multierror.Append(result, errors.New("require both tls cert-file and key-file"))
+	}
+	if c.PoolSize < 0 {
+		result = multierror.Append(result, fmt.Errorf("invalid pool-size: %d", c.PoolSize))
+	}
+	return result.ErrorOrNil()
+}
+
+type MemoryConfig struct {
+	// Size is the maximum number of items. Less frequently used items are evicted if exceeded.
+	Size int `json:"size" yaml:"size"`
+}
+
+// LocalConfig represents configs of in-process cache in front of redis.
+type LocalConfig struct {
+	Enabled bool          `json:"enabled" yaml:"enabled"`
+	Size    int           `json:"size" yaml:"size"`
+	TTL     time.Duration `json:"ttl" yaml:"ttl"`
+	// Channel is a redis pub/sub channel to broadcast invalidated keys to other instances.
+	Channel string `json:"channel" yaml:"channel"`
+}
+
+// Stats represents hits and misses of each cache tier.
+type Stats struct {
+	LocalHits   uint64
+	LocalMisses uint64
+	RedisHits   uint64
+	RedisMisses uint64
+	// DecodeErrors is a number of cached items failed to decode, which are handled as cache misses.
+	DecodeErrors uint64
+	// Pool is statistics of the redis connection pool. Nil if the cache is not on redis.
+	Pool *redis.PoolStats
+}
+
+// StatsReporter reports Stats of a Cacher.
+type StatsReporter interface {
+	Stats() *Stats
+}
+
+// Option configures how an item is cached.
+type Option func(o *options)
+
+type options struct {
+	ttl         time.Duration
+	tags        []string
+	negativeErr error
+	lock        bool
+	staleTTL    time.Duration
+}
+
+func newOptions(opts ...Option) *options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &o
+}
+
+// WithTTL overrides Config.TTL of items.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithTags associates items with given tags, so the items can be removed by Cacher.InvalidateTags.
+func WithTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithNegative caches the result of FetchFunc for Config.NegativeTTL if it returns an error matched with given err.
+// Fetch returns the err without calling FetchFunc while the result is cached.
+func WithNegative(err error) Option {
+	return func(o *options) {
+		o.negativeErr = err
+	}
+}
+
+// WithLock acquires a distributed lock for Config.LockTTL before calling FetchFunc,
+// so only one instance calls FetchFunc for the same key. Other instances wait for the item to be cached.
+func WithLock() Option {
+	return func(o *options) {
+		o.lock = true
+	}
+}
+
+// WithStale keeps an expired item for given ttl more and returns it while FetchFunc is called in background.
+func WithStale(ttl time.Duration) Option {
+	return func(o *options) {
+		o.staleTTL = ttl
+	}
 }
 
 //go:generate mockery --name Cacher --filename cache_mock.go
@@ -43,29 +209,105 @@ type Cacher interface {
 	io.Closer
 	// Fetch retrieves the item from the cache. If the item does not exist,
 	// calls given FetchFunc to create a new item and sets to the cache.
-	Fetch(ctx context.Context, key string, value interface{}, fetchFunc FetchFunc) error
+	// The FetchFunc is called once at a time for the same key in a process,
+	// but each caller returns ctx.Err() as soon as its ctx is done without cancelling the FetchFunc.
+	Fetch(ctx context.Context, key string, value interface{}, fetchFunc FetchFunc, opts ...Option) error
 
 	// Get gets an item for the given computeKey.
 	Get(ctx context.Context, key string, value interface{}) error
 
 	// Set adds an item to the cache.
-	Set(ctx context.Context, key string, value interface{}) error
+	Set(ctx context.Context, key string, value interface{}, opts ...Option) error
+
+	// MGet gets items for the given keys to values which are pointers in the same order of the keys.
+	// Returns keys not exist in the cache.
+	MGet(ctx context.Context, keys []string, values []interface{}) ([]string, error)
+
+	// MSet adds items of given key-value pairs to the cache.
+	MSet(ctx context.Context, items map[string]interface{}, opts ...Option) error
 
 	// Exists returns a true if the given computeKey is exists, otherwise false.
 	Exists(ctx context.Context, key string) (bool, error)
 
 	// Delete removes an item from the cache.
 	Delete(ctx context.Context, key string) error
+
+	// InvalidateTags removes all items associated with given tags.
+	InvalidateTags(ctx context.Context, tags ...string) error
 }
 
-func NewCacher(conf *Config) (Cacher, error) {
+// Validate returns errors of invalid configs. Configs of a disabled cache are not validated.
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	var result *multierror.Error
+	switch c.Type {
+	case "redis":
+		result = multierror.Append(result, multierror.Prefix(c.Redis.Validate(), "redis:"))
+	case "memory":
+		if c.Memory.Size < 0 {
+			result = multierror.Append(result, fmt.Errorf("invalid memory.size: %d", c.Memory.Size))
+		}
+	default:
+		result = multierror.Append(result, fmt.Errorf("unknown type: %q", c.Type))
+	}
+	if _, err := NewCodec(c.Codec); err != nil {
+		result = multierror.Append(result, err)
+	}
+	if c.TTL <= 0 {
+		result = multierror.Append(result, fmt.Errorf("ttl must be positive: %s", c.TTL))
+	}
+	if c.TTLJitter < 0 || c.TTLJitter > 1 {
+		result = multierror.Append(result, fmt.Errorf("ttl-jitter must be in [0, 1]: %v", c.TTLJitter))
+	}
+	if c.NegativeTTL < 0 || c.LockTTL < 0 || c.LoadTimeout < 0 {
+		result = multierror.Append(result, errors.New("negative-ttl, lock-ttl and load-timeout must not be negative"))
+	}
+	if c.Local.Enabled {
+		if c.Type != "redis" {
+			result = multierror.Append(result, errors.New("local cache requires redis type"))
+		}
+		if c.Local.TTL <= 0 || c.Local.Channel == "" {
+			result = multierror.Append(result, errors.New("local cache requires positive ttl and channel"))
+		}
+	}
+	if c.Breaker.Enabled && (c.Breaker.FailureThreshold <= 0 || c.Breaker.OpenTimeout <= 0 || c.Breaker.HalfOpenRequests <= 0) {
+		result = multierror.Append(result, errors.New("breaker requires positive failure-threshold, open-timeout and half-open-requests"))
+	}
+	return result.ErrorOrNil()
+}
+
+// NewCacher returns a Cacher of given conf recording metrics to given recorder if not nil.
+// Logs without a logger in the context are written by given logger or the default logger if nil.
+func NewCacher(conf *Config, recorder Recorder, logger *zap.SugaredLogger) (Cacher, error) {
 	if !conf.Enabled {
 		return nil, nil
 	}
+	codec, err := NewCodec(conf.Codec)
+	if err != nil {
+		return nil, err
+	}
+	var cacher Cacher
 	switch conf.Type {
 	case "redis":
-		return newRedisCacher(conf)
+		cacher, err = newRedisCacher(conf, recorder, logger)
+	case "memory":
+		cacher, err = newMemoryCacher(conf, recorder, logger)
 	default:
 		return nil, fmt.Errorf("unknown cache type: %s", conf.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if conf.Breaker.Enabled {
+		return newBreakerCacher(&conf.Breaker, codec, cacher, recorder, logger), nil
+	}
+	return cacher, nil
+}
+
+// loggerOf returns a logger named with loggerName from given ctx, otherwise of given logger
+// or the default logger if nil.
+func loggerOf(ctx context.Context, logger *zap.SugaredLogger) *zap.SugaredLogger {
+	return logging.FromContextOr(ctx, logger).Named(loggerName)
 }
@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -15,7 +16,7 @@ func testFetch(t *testing.T, cacher Cacher) {
 		assert.NoError(t, cacher.Set(context.TODO(), key, value))
 
 		var find string
-		cacher.Fetch(context.TODO(), key, &find, func() (interface{}, error) {
+		cacher.Fetch(context.TODO(), key, &find, func(context.Context) (interface{}, error) {
 			t.Fail()
 			return nil, nil
 		})
@@ -31,7 +32,7 @@ func testFetch(t *testing.T, cacher Cacher) {
 			find   string
 			called = false
 		)
-		err := cacher.Fetch(context.TODO(), key, &find, func() (interface{}, error) {
+		err := cacher.Fetch(context.TODO(), key, &find, func(context.Context) (interface{}, error) {
 			called = true
 			return value, nil
 		})
@@ -169,3 +170,100 @@ func testSet(t *testing.T, cacher Cacher) {
 		assert.True(t, ok)
 	})
 }
+
+func testMGet(t *testing.T, cacher Cacher) {
+	var (
+		key1 = uuid.NewString()
+		key2 = uuid.NewString()
+		key3 = uuid.NewString()
+	)
+	assert.NoError(t, cacher.MSet(context.TODO(), map[string]interface{}{key1: "value1", key3: "value3"}))
+
+	t.Run("Exist Items", func(t *testing.T) {
+		var find1, find2, find3 string
+		missing, err := cacher.MGet(context.TODO(), []string{key1, key2, key3}, []interface{}{&find1, &find2, &find3})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{key2}, missing)
+		assert.Equal(t, "value1", find1)
+		assert.Empty(t, find2)
+		assert.Equal(t, "value3", find3)
+	})
+
+	t.Run("Invalid Values", func(t *testing.T) {
+		var find1 string
+		_, err := cacher.MGet(context.TODO(), []string{key1, key2}, []interface{}{&find1})
+
+		assert.ErrorIs(t, err, ErrInvalidValue)
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		var find1 string
+		_, err := cacher.MGet(context.TODO(), []string{""}, []interface{}{&find1})
+
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+}
+
+func testInvalidateTags(t *testing.T, cacher Cacher) {
+	var (
+		tag1 = uuid.NewString()
+		tag2 = uuid.NewString()
+		key1 = uuid.NewString()
+		key2 = uuid.NewString()
+		key3 = uuid.NewString()
+	)
+	assert.NoError(t, cacher.Set(context.TODO(), key1, "value1", WithTags(tag1)))
+	assert.NoError(t, cacher.MSet(context.TODO(), map[string]interface{}{key2: "value2"}, WithTags(tag1, tag2)))
+	var find string
+	assert.NoError(t, cacher.Fetch(context.TODO(), key3, &find, func(context.Context) (interface{}, error) {
+		return "value3", nil
+	}, WithTags(tag2)))
+
+	assert.NoError(t, cacher.InvalidateTags(context.TODO(), tag1))
+
+	for key, expected := range map[string]bool{key1: false, key2: false, key3: true} {
+		ok, err := cacher.Exists(context.TODO(), key)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, ok)
+	}
+	assert.NoError(t, cacher.InvalidateTags(context.TODO(), tag2))
+	ok, err := cacher.Exists(context.TODO(), key3)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// keys of tags are reserved.
+	assert.ErrorIs(t, cacher.Set(context.TODO(), "tag:"+tag1, "value1"), ErrInvalidKey)
+	assert.ErrorIs(t, cacher.Get(context.TODO(), "tag:"+tag1, &find), ErrInvalidKey)
+	assert.ErrorIs(t, cacher.Delete(context.TODO(), "tag:"+tag1), ErrInvalidKey)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	conf := Config{
+		Enabled: true,
+		Type:    "redis",
+		TTL:     time.Minute,
+		Redis:   RedisConfig{Endpoints: []string{"localhost:6379"}},
+	}
+	assert.NoError(t, conf.Validate())
+	assert.NoError(t, (&Config{Type: "unknown"}).Validate())
+
+	conf.Redis.Endpoints = nil
+	conf.Codec = "xml"
+	conf.TTL = 0
+	conf.TTLJitter = 2
+	conf.Breaker.Enabled = true
+
+	err := conf.Validate()
+
+	assert.Error(t, err)
+	for _, msg := range []string{
+		"redis: require at least one endpoint",
+		"unknown cache codec",
+		"ttl must be positive",
+		"ttl-jitter must be in [0, 1]",
+		"breaker requires",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/go-redis/cache/v8"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	CodecMsgpack = "msgpack"
+	CodecJSON    = "json"
+	CodecGob     = "gob"
+)
+
+// Codec encodes values of cached items.
+type Codec interface {
+	// Name is stored with encoded values, so values encoded by other codecs are handled as cache misses.
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(b []byte, v interface{}) error
+}
+
+// NewCodec returns a Codec of given name. Defaults to msgpack if the name is empty.
+func NewCodec(name string) (Codec, error) {
+	switch name {
+	case "", CodecMsgpack:
+		// go-redis/cache encodes values with msgpack and compresses large values.
+		return &msgpackCodec{cache: cache.New(&cache.Options{})}, nil
+	case CodecJSON:
+		return jsonCodec{}, nil
+	case CodecGob:
+		return gobCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache codec: %s", name)
+	}
+}
+
+type msgpackCodec struct {
+	cache *cache.Cache
+}
+
+func (c *msgpackCodec) Name() string {
+	return CodecMsgpack
+}
+
+func (c *msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return c.cache.Marshal(v)
+}
+
+func (c *msgpackCodec) Unmarshal(b []byte, v interface{}) error {
+	return c.cache.Unmarshal(b, v)
+}
+
+// jsonAPI encodes fields by "cache" tags instead of "json" tags, so fields hidden from responses by `json:"-"`,
+// e.g. passwords and versions of models, are cached too. Fields are named by their names if not tagged.
+var jsonAPI = jsoniter.Config{
+	EscapeHTML:             true,
+	SortMapKeys:            true,
+	ValidateJsonRawMessage: true,
+	TagKey:                 "cache",
+}.Froze()
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return CodecJSON
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return jsonAPI.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(b []byte, v interface{}) error {
+	return jsonAPI.Unmarshal(b, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string {
+	return CodecGob
+}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(b []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCodecItem struct {
+	Name      string
+	Tags      []string
+	CreatedAt time.Time
+}
+
+func TestNewCodec(t *testing.T) {
+	for _, name := range []string{CodecMsgpack, CodecJSON, CodecGob} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := NewCodec(name)
+			assert.NoError(t, err)
+			assert.Equal(t, name, codec.Name())
+			item := testCodecItem{Name: "item1", Tags: []string{"tag1"}, CreatedAt: time.Unix(1700000000, 0).UTC()}
+
+			b, err := codec.Marshal(&item)
+			assert.NoError(t, err)
+			var find testCodecItem
+			assert.NoError(t, codec.Unmarshal(b, &find))
+			assert.Equal(t, item.Name, find.Name)
+			assert.Equal(t, item.Tags, find.Tags)
+			assert.True(t, item.CreatedAt.Equal(find.CreatedAt))
+		})
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		codec, err := NewCodec("")
+		assert.NoError(t, err)
+		assert.Equal(t, CodecMsgpack, codec.Name())
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := NewCodec("xml")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+const defaultLockRetryInterval = 100 * time.Millisecond
+
+var (
+	ErrLockNotAcquired   = errors.New("lock is acquired by others")
+	ErrLockNotHeld       = errors.New("lock is not held")
+	ErrLockerUnavailable = errors.New("locker requires the cache enabled with redis type")
+)
+
+// LockerConfig represents configs of Locker.
+type LockerConfig struct {
+	// RetryInterval is an interval to retry acquiring a lock while blocking.
+	RetryInterval time.Duration `json:"retry-interval" yaml:"retry-interval"`
+}
+
+//go:generate mockery --name Locker --filename locker_mock.go
+type Locker interface {
+	io.Closer
+	// TryLock acquires a lock of given key for ttl without blocking.
+	// Returns ErrLockNotAcquired if the lock is acquired by others.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+
+	// Lock acquires a lock of given key for ttl. Blocks until the lock is acquired or the ctx is done.
+	Lock(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+}
+
+// Lock is an acquired lock.
+type Lock interface {
+	// Key returns the key of the lock.
+	Key() string
+
+	// Token returns a fencing token which increases monotonically whenever the key is acquired.
+	// Resources guarded by the lock should reject writes with a token less than the last seen one,
+	// since the lock can be expired while the holder is paused.
+	Token() int64
+
+	// Extend extends the lease of the lock to ttl from now.
+	// Returns ErrLockNotHeld if the lock is expired or acquired by others.
+	Extend(ctx context.Context, ttl time.Duration) error
+
+	// Release releases the lock. Returns ErrLockNotHeld if the lock is expired or acquired by others.
+	Release(ctx context.Context) error
+}
+
+// NewLocker returns a Locker on the redis client of given cacher created by NewCacher,
+// so that locks are exclusive across processes. Returns ErrLockerUnavailable if the cacher is not on redis,
+// e.g. the cache is disabled. Closing the Locker does not close the client shared with the cacher.
+func NewLocker(conf *Config, cacher Cacher) (Locker, error) {
+	cli := redisClientOf(cacher)
+	if cli == nil {
+		return nil, ErrLockerUnavailable
+	}
+	return newRedisLocker(conf, cli), nil
+}
+
+// NewMemoryLocker returns an in-process Locker whose locks are exclusive only within the process.
+func NewMemoryLocker(conf *Config) Locker {
+	return newMemoryLocker(conf)
+}
+
+// tryLockFunc acquires a lock without blocking.
+type tryLockFunc func(ctx context.Context, key string, ttl time.Duration) (Lock, error)
+
+// lockWithRetry calls given tryLock every interval until the lock is acquired or the ctx is done.
+func lockWithRetry(ctx context.Context, tryLock tryLockFunc, interval time.Duration, key string, ttl time.Duration) (Lock, error) {
+	if interval <= 0 {
+		interval = defaultLockRetryInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		l, err := tryLock(ctx, key, ttl)
+		if !errors.Is(err, ErrLockNotAcquired) {
+			return l, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
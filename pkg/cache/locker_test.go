@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisLocker(t *testing.T) {
+	s := miniredis.RunT(t)
+	conf := Config{
+		Enabled: true,
+		Type:    "redis",
+		TTL:     time.Minute,
+		Redis:   RedisConfig{Endpoints: []string{s.Addr()}},
+		Breaker: BreakerConfig{Enabled: true, FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenRequests: 1},
+		Locker:  LockerConfig{RetryInterval: 10 * time.Millisecond},
+	}
+	cacher, err := NewCacher(&conf, newTestRecorder(), nil)
+	assert.NoError(t, err)
+	defer cacher.Close()
+	locker, err := NewLocker(&conf, cacher)
+	assert.NoError(t, err)
+
+	testLocker(t, locker, func(d time.Duration) {
+		s.FastForward(d)
+	})
+
+	// the client shared with the cacher is not closed.
+	assert.NoError(t, locker.Close())
+	assert.NoError(t, cacher.Set(context.TODO(), "key1", "value1"))
+}
+
+func TestNewLocker_Unavailable(t *testing.T) {
+	_, err := NewLocker(&Config{}, nil)
+	assert.ErrorIs(t, err, ErrLockerUnavailable)
+
+	cacher, err := NewCacher(&Config{Enabled: true, Type: "memory", TTL: time.Minute}, nil, nil)
+	assert.NoError(t, err)
+	defer cacher.Close()
+	_, err = NewLocker(&Config{}, cacher)
+	assert.ErrorIs(t, err, ErrLockerUnavailable)
+}
+
+func TestMemoryLocker(t *testing.T) {
+	locker := NewMemoryLocker(&Config{Locker: LockerConfig{RetryInterval: 10 * time.Millisecond}})
+	defer locker.Close()
+
+	testLocker(t, locker, time.Sleep)
+}
+
+// testLocker tests given locker. The expire waits for locks to be expired.
+func testLocker(t *testing.T, locker Locker, expire func(d time.Duration)) {
+	t.Run("TryLock", func(t *testing.T) {
+		l, err := locker.TryLock(context.TODO(), "try-lock", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, "try-lock", l.Key())
+
+		_, err = locker.TryLock(context.TODO(), "try-lock", time.Minute)
+		assert.ErrorIs(t, err, ErrLockNotAcquired)
+		assert.NoError(t, l.Release(context.TODO()))
+		assert.ErrorIs(t, l.Release(context.TODO()), ErrLockNotHeld)
+
+		l2, err := locker.TryLock(context.TODO(), "try-lock", time.Minute)
+		assert.NoError(t, err)
+		assert.Greater(t, l2.Token(), l.Token())
+		assert.NoError(t, l2.Release(context.TODO()))
+	})
+
+	t.Run("Expire And Extend", func(t *testing.T) {
+		l, err := locker.TryLock(context.TODO(), "expire", 50*time.Millisecond)
+		assert.NoError(t, err)
+		assert.NoError(t, l.Extend(context.TODO(), 200*time.Millisecond))
+
+		expire(100 * time.Millisecond)
+
+		_, err = locker.TryLock(context.TODO(), "expire", time.Minute)
+		assert.ErrorIs(t, err, ErrLockNotAcquired)
+
+		expire(200 * time.Millisecond)
+
+		l2, err := locker.TryLock(context.TODO(), "expire", time.Minute)
+		assert.NoError(t, err)
+		assert.Greater(t, l2.Token(), l.Token())
+		assert.ErrorIs(t, l.Extend(context.TODO(), time.Minute), ErrLockNotHeld)
+		assert.ErrorIs(t, l.Release(context.TODO()), ErrLockNotHeld)
+		assert.NoError(t, l2.Release(context.TODO()))
+	})
+
+	t.Run("Lock", func(t *testing.T) {
+		var (
+			wg       sync.WaitGroup
+			holders  int32
+			maxHolds int32
+			tokens   sync.Map
+		)
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				l, err := locker.Lock(context.TODO(), "lock", time.Minute)
+				if !assert.NoError(t, err) {
+					return
+				}
+				_, loaded := tokens.LoadOrStore(l.Token(), struct{}{})
+				assert.False(t, loaded)
+				if n := atomic.AddInt32(&holders, 1); n > atomic.LoadInt32(&maxHolds) {
+					atomic.StoreInt32(&maxHolds, n)
+				}
+				time.Sleep(20 * time.Millisecond)
+				atomic.AddInt32(&holders, -1)
+				assert.NoError(t, l.Release(context.TODO()))
+			}()
+		}
+		wg.Wait()
+
+		assert.EqualValues(t, 1, maxHolds)
+	})
+
+	t.Run("Lock Canceled", func(t *testing.T) {
+		l, err := locker.TryLock(context.TODO(), "canceled", time.Minute)
+		assert.NoError(t, err)
+		defer l.Release(context.TODO())
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err = locker.Lock(ctx, "canceled", time.Minute)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		_, err := locker.TryLock(context.TODO(), "", time.Minute)
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/go-tinylfu"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMemorySize = 10000
+	// memorySamples is a number of recent accesses to estimate frequencies of items.
+	memorySamples = 100000
+)
+
+var _ store = (*memoryStore)(nil)
+
+func newMemoryCacher(conf *Config, recorder Recorder, logger *zap.SugaredLogger) (Cacher, error) {
+	codec, err := NewCodec(conf.Codec)
+	if err != nil {
+		return nil, err
+	}
+	size := conf.Memory.Size
+	if size <= 0 {
+		size = defaultMemorySize
+	}
+	m := memoryStore{
+		// items are evicted by TinyLFU policy if exceed the size.
+		lfu:  tinylfu.New(size, memorySamples),
+		tags: make(map[string]map[string]struct{}),
+	}
+	if recorder != nil {
+		m.onEvict = func(key string) {
+			recorder.RecordCacheEviction(keyGroup(conf.Prefix, key))
+		}
+	}
+	return newStoreCacher(conf, codec, newRecordingStore(conf.Prefix, &m, recorder), logger), nil
+}
+
+// memoryStore is an in-process store.
+type memoryStore struct {
+	mu   sync.Mutex
+	lfu  *tinylfu.T
+	tags map[string]map[string]struct{}
+	// deleting is true while items are removed explicitly, so they are not counted as evictions.
+	deleting bool
+	// onEvict is called with a key of an item evicted to free capacity.
+	onEvict func(key string)
+}
+
+func (m *memoryStore) get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	v, ok := m.lfu.Get(key)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	return v.([]byte), nil
+}
+
+func (m *memoryStore) mget(_ context.Context, keys []string) ([][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	bs := make([][]byte, len(keys))
+	for i, key := range keys {
+		if v, ok := m.lfu.Get(key); ok {
+			bs[i] = v.([]byte)
+		}
+	}
+	return bs, nil
+}
+
+func (m *memoryStore) set(_ context.Context, items ...*storeItem) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, item := range items {
+		var (
+			key     = item.key
+			tagKeys = item.tagKeys
+		)
+		// removes an existing item first since tinylfu does not replace it.
+		m.remove(key)
+		lfuItem := tinylfu.Item{
+			Key:   key,
+			Value: item.value,
+		}
+		if item.ttl > 0 {
+			lfuItem.ExpireAt = time.Now().Add(item.ttl)
+		}
+		expireAt := lfuItem.ExpireAt
+		lfuItem.OnEvict = func() {
+			m.untag(key, tagKeys)
+			expired := !expireAt.IsZero() && !time.Now().Before(expireAt)
+			if !m.deleting && !expired && m.onEvict != nil {
+				m.onEvict(key)
+			}
+		}
+		m.lfu.Set(&lfuItem)
+		for _, tagKey := range tagKeys {
+			keys, ok := m.tags[tagKey]
+			if !ok {
+				keys = make(map[string]struct{})
+				m.tags[tagKey] = keys
+			}
+			keys[key] = struct{}{}
+		}
+	}
+	return nil
+}
+
+func (m *memoryStore) del(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		m.remove(key)
+	}
+	return nil
+}
+
+func (m *memoryStore) invalidateTags(_ context.Context, tagKeys ...string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var removed []string
+	for _, tagKey := range tagKeys {
+		for key := range m.tags[tagKey] {
+			m.remove(key)
+			removed = append(removed, key)
+		}
+		delete(m.tags, tagKey)
+	}
+	return removed, nil
+}
+
+// remove removes an item of given key. It is called while holding the lock.
+func (m *memoryStore) remove(key string) {
+	m.deleting = true
+	m.lfu.Del(key)
+	m.deleting = false
+}
+
+// untag removes given key from tags. It is called while holding the lock.
+func (m *memoryStore) untag(key string, tagKeys []string) {
+	for _, tagKey := range tagKeys {
+		keys := m.tags[tagKey]
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(m.tags, tagKey)
+		}
+	}
+}
+
+func (m *memoryStore) publish(_ context.Context, _ ...string) {}
+
+// lock is not supported since items are fetched once at a time in a process.
+func (m *memoryStore) lock(_ context.Context, _ string, _ time.Duration) (func(), bool, error) {
+	return nil, true, nil
+}
+
+func (m *memoryStore) close() error {
+	return nil
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Locker = (*memoryLocker)(nil)
+
+// memoryLocker is an in-process Locker.
+type memoryLocker struct {
+	mu            sync.Mutex
+	locks         map[string]*memoryLock
+	tokens        map[string]int64
+	retryInterval time.Duration
+}
+
+func newMemoryLocker(conf *Config) *memoryLocker {
+	return &memoryLocker{
+		locks:         make(map[string]*memoryLock),
+		tokens:        make(map[string]int64),
+		retryInterval: conf.Locker.RetryInterval,
+	}
+}
+
+func (m *memoryLocker) TryLock(_ context.Context, key string, ttl time.Duration) (Lock, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if l, ok := m.locks[key]; ok && now.Before(l.expireAt) {
+		return nil, ErrLockNotAcquired
+	}
+	m.tokens[key]++
+	l := memoryLock{locker: m, key: key, token: m.tokens[key], expireAt: now.Add(ttl)}
+	m.locks[key] = &l
+	return &l, nil
+}
+
+func (m *memoryLocker) Lock(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	return lockWithRetry(ctx, m.TryLock, m.retryInterval, key, ttl)
+}
+
+func (m *memoryLocker) Close() error {
+	return nil
+}
+
+// held returns true if given l is the current lock of its key and not expired. It is called while holding the lock.
+func (m *memoryLocker) held(l *memoryLock) bool {
+	return m.locks[l.key] == l && time.Now().Before(l.expireAt)
+}
+
+type memoryLock struct {
+	locker   *memoryLocker
+	key      string
+	token    int64
+	expireAt time.Time
+}
+
+func (l *memoryLock) Key() string {
+	return l.key
+}
+
+func (l *memoryLock) Token() int64 {
+	return l.token
+}
+
+func (l *memoryLock) Extend(_ context.Context, ttl time.Duration) error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	if !l.locker.held(l) {
+		return ErrLockNotHeld
+	}
+	l.expireAt = time.Now().Add(ttl)
+	return nil
+}
+
+func (l *memoryLock) Release(_ context.Context) error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	if !l.locker.held(l) {
+		return ErrLockNotHeld
+	}
+	delete(l.locker.locks, l.key)
+	return nil
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MemoryCacheSuite struct {
+	suite.Suite
+	cacher Cacher
+}
+
+func TestMemoryCache(t *testing.T) {
+	suite.Run(t, new(MemoryCacheSuite))
+}
+
+func (s *MemoryCacheSuite) SetupSuite() {
+	var err error
+	s.cacher, err = NewCacher(&Config{
+		Enabled: true,
+		Prefix:  "test-",
+		Type:    "memory",
+		TTL:     time.Minute,
+	}, nil, nil)
+	s.NoError(err)
+}
+
+func (s *MemoryCacheSuite) TearDownSuite() {
+	s.NoError(s.cacher.Close())
+}
+
+func (s *MemoryCacheSuite) TestFetch() {
+	testFetch(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestFetch_SingleFlight() {
+	var (
+		wg    sync.WaitGroup
+		calls int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var find string
+			s.NoError(s.cacher.Fetch(context.TODO(), "single-flight", &find, func(context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(100 * time.Millisecond)
+				return "value1", nil
+			}))
+			s.Equal("value1", find)
+		}()
+	}
+	wg.Wait()
+
+	s.EqualValues(1, atomic.LoadInt32(&calls))
+}
+
+func (s *MemoryCacheSuite) TestGet() {
+	testGet(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestSet() {
+	testSet(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestExists() {
+	testExists(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestDelete() {
+	testDelete(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestMGet() {
+	testMGet(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestInvalidateTags() {
+	testInvalidateTags(s.T(), s.cacher)
+}
+
+func (s *MemoryCacheSuite) TestExpire() {
+	cacher, err := newMemoryCacher(&Config{TTL: 100 * time.Millisecond}, nil, nil)
+	s.NoError(err)
+	s.NoError(cacher.Set(context.TODO(), "key1", "value1"))
+
+	time.Sleep(200 * time.Millisecond)
+
+	var find string
+	s.ErrorIs(cacher.Get(context.TODO(), "key1", &find), ErrCacheMiss)
+}
+
+func (s *MemoryCacheSuite) TestEvict() {
+	cacher, err := newMemoryCacher(&Config{TTL: time.Minute, Memory: MemoryConfig{Size: 10}}, nil, nil)
+	s.NoError(err)
+
+	for i := 0; i < 100; i++ {
+		s.NoError(cacher.Set(context.TODO(), fmt.Sprintf("key%d", i), i))
+	}
+
+	var exists int
+	for i := 0; i < 100; i++ {
+		if ok, _ := cacher.Exists(context.TODO(), fmt.Sprintf("key%d", i)); ok {
+			exists++
+		}
+	}
+	s.LessOrEqual(exists, 10)
+}
+
+func (s *MemoryCacheSuite) TestSet_TTL() {
+	s.NoError(s.cacher.Set(context.TODO(), "set-ttl", "value1", WithTTL(100*time.Millisecond)))
+
+	time.Sleep(200 * time.Millisecond)
+
+	var find string
+	s.ErrorIs(s.cacher.Get(context.TODO(), "set-ttl", &find), ErrCacheMiss)
+}
@@ -67,13 +67,20 @@ func (_m *Cacher) Exists(ctx context.Context, key string) (bool, error) {
 	return r0, r1
 }
 
-// Fetch provides a mock function with given fields: ctx, key, value, fetchFunc
-func (_m *Cacher) Fetch(ctx context.Context, key string, value interface{}, fetchFunc cache.FetchFunc) error {
-	ret := _m.Called(ctx, key, value, fetchFunc)
+// Fetch provides a mock function with given fields: ctx, key, value, fetchFunc, opts
+func (_m *Cacher) Fetch(ctx context.Context, key string, value interface{}, fetchFunc cache.FetchFunc, opts ...cache.Option) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key, value, fetchFunc)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, cache.FetchFunc) error); ok {
-		r0 = rf(ctx, key, value, fetchFunc)
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, cache.FetchFunc, ...cache.Option) error); ok {
+		r0 = rf(ctx, key, value, fetchFunc, opts...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -95,13 +102,88 @@ func (_m *Cacher) Get(ctx context.Context, key string, value interface{}) error
 	return r0
 }
 
-// Set provides a mock function with given fields: ctx, key, value
-func (_m *Cacher) Set(ctx context.Context, key string, value interface{}) error {
-	ret := _m.Called(ctx, key, value)
+// InvalidateTags provides a mock function with given fields: ctx, tags
+func (_m *Cacher) InvalidateTags(ctx context.Context, tags ...string) error {
+	_va := make([]interface{}, len(tags))
+	for _i := range tags {
+		_va[_i] = tags[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}) error); ok {
-		r0 = rf(ctx, key, value)
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) error); ok {
+		r0 = rf(ctx, tags...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MGet provides a mock function with given fields: ctx, keys, values
+func (_m *Cacher) MGet(ctx context.Context, keys []string, values []interface{}) ([]string, error) {
+	ret := _m.Called(ctx, keys, values)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, []interface{}) ([]string, error)); ok {
+		return rf(ctx, keys, values)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, []interface{}) []string); ok {
+		r0 = rf(ctx, keys, values)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, []interface{}) error); ok {
+		r1 = rf(ctx, keys, values)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MSet provides a mock function with given fields: ctx, items, opts
+func (_m *Cacher) MSet(ctx context.Context, items map[string]interface{}, opts ...cache.Option) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, items)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, map[string]interface{}, ...cache.Option) error); ok {
+		r0 = rf(ctx, items, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Set provides a mock function with given fields: ctx, key, value, opts
+func (_m *Cacher) Set(ctx context.Context, key string, value interface{}, opts ...cache.Option) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, key, value)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, ...cache.Option) error); ok {
+		r0 = rf(ctx, key, value, opts...)
 	} else {
 		r0 = ret.Error(0)
 	}
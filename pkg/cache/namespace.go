@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+)
+
+// Namespace is a namespace of keys of a cached type versioned by the schema of the type.
+// Keys are changed whenever the schema is changed, e.g. a field is added or renamed,
+// so instances of a rolling deploy never decode items cached by other schemas.
+type Namespace struct {
+	name    string
+	version string
+}
+
+// NewNamespace returns a Namespace of given name versioned by the schema of given v's type.
+func NewNamespace(name string, v interface{}) Namespace {
+	h := fnv.New32a()
+	writeSchema(h, reflect.TypeOf(v), make(map[reflect.Type]struct{}))
+	return Namespace{name: name, version: fmt.Sprintf("%08x", h.Sum32())}
+}
+
+// Name returns the name of the namespace.
+func (n Namespace) Name() string {
+	return n.name
+}
+
+// Version returns the schema version of the namespace.
+func (n Namespace) Version() string {
+	return n.version
+}
+
+// Key returns a key of given id in the namespace.
+func (n Namespace) Key(id string) string {
+	return fmt.Sprintf("%s.%s.%s", n.name, n.version, id)
+}
+
+// writeSchema writes names, types and tags of fields of given t to w.
+func writeSchema(w io.Writer, t reflect.Type, visited map[reflect.Type]struct{}) {
+	if t == nil {
+		return
+	}
+	fmt.Fprintf(w, "%s(%s)", t.Kind(), t.String())
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		writeSchema(w, t.Elem(), visited)
+	case reflect.Map:
+		writeSchema(w, t.Key(), visited)
+		writeSchema(w, t.Elem(), visited)
+	case reflect.Struct:
+		if _, ok := visited[t]; ok {
+			return
+		}
+		visited[t] = struct{}{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			fmt.Fprintf(w, "{%s %q ", f.Name, f.Tag)
+			writeSchema(w, f.Type, visited)
+			fmt.Fprint(w, "}")
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSchemaV1 struct {
+	ID   uint
+	Name string
+}
+
+type testSchemaV2 struct {
+	ID       uint
+	Name     string
+	Nickname string
+}
+
+type testSchemaRecursive struct {
+	ID       uint
+	Children []*testSchemaRecursive
+}
+
+func TestNewNamespace(t *testing.T) {
+	ns := NewNamespace("item", testSchemaV1{})
+
+	assert.Equal(t, "item", ns.Name())
+	assert.Len(t, ns.Version(), 8)
+	assert.Equal(t, "item."+ns.Version()+".1", ns.Key("1"))
+	assert.Equal(t, ns, NewNamespace("item", testSchemaV1{}))
+	assert.NotEqual(t, ns.Version(), NewNamespace("item", testSchemaV2{}).Version())
+	assert.NotEmpty(t, NewNamespace("item", testSchemaRecursive{}).Version())
+}
@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Recorder records metrics of cache operations.
+// Keys are grouped by their prefix before the first "." to keep cardinality of labels bounded.
+type Recorder interface {
+	// RecordCacheOperation observes elapsed time of given op on items of given key group.
+	// errType is empty if the op is succeeded.
+	RecordCacheOperation(op, group string, elapsed time.Duration, errType string)
+
+	// RecordCachePayload observes a size of an encoded item read or written by given op.
+	RecordCachePayload(op, group string, size int)
+
+	// RecordCacheEviction increases count of items of given key group evicted to free capacity.
+	// Only evictions of the memory store are recorded since redis evicts items by itself.
+	RecordCacheEviction(group string)
+}
+
+type noopRecorder struct{}
+
+func (noopRecorder) RecordCacheOperation(string, string, time.Duration, string) {}
+
+func (noopRecorder) RecordCachePayload(string, string, int) {}
+
+func (noopRecorder) RecordCacheEviction(string) {}
+
+const (
+	defaultKeyGroup = "default"
+	tagKeyGroup     = "tag"
+	// tagKeyPrefix is a prefix of keys of tags which is not allowed for keys of items.
+	tagKeyPrefix = tagKeyGroup + ":"
+)
+
+// keyGroup returns a group of given k key stored with given prefix.
+func keyGroup(prefix, k string) string {
+	k = strings.TrimPrefix(k, prefix)
+	if strings.HasPrefix(k, tagKeyPrefix) {
+		return tagKeyGroup
+	}
+	if i := strings.IndexByte(k, '.'); i > 0 {
+		return k[:i]
+	}
+	return defaultKeyGroup
+}
+
+// errorType returns a type of given err for metrics. Returns empty if the err is not a failure.
+func errorType(err error) string {
+	var (
+		netErr   net.Error
+		redisErr redis.Error
+	)
+	switch {
+	case err == nil, errors.Is(err, ErrCacheMiss):
+		return ""
+	case errors.Is(err, ErrInvalidKey):
+		return "invalid_key"
+	case errors.Is(err, ErrInvalidValue):
+		return "invalid_value"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	case errors.As(err, &redisErr):
+		return "redis"
+	default:
+		return "other"
+	}
+}
+
+var _ store = (*recordingStore)(nil)
+
+// recordingStore decorates a store to record metrics of each operation.
+// Bulk operations are recorded with the group of the first key.
+type recordingStore struct {
+	store
+	prefix   string
+	recorder Recorder
+}
+
+func newRecordingStore(prefix string, s store, recorder Recorder) store {
+	if recorder == nil {
+		return s
+	}
+	return &recordingStore{store: s, prefix: prefix, recorder: recorder}
+}
+
+func (r *recordingStore) redisClient() redis.UniversalClient {
+	return redisClientOf(r.store)
+}
+
+func (r *recordingStore) get(ctx context.Context, key string) ([]byte, error) {
+	start := time.Now()
+	b, err := r.store.get(ctx, key)
+	group := keyGroup(r.prefix, key)
+	r.recorder.RecordCacheOperation("get", group, time.Since(start), errorType(err))
+	if err == nil {
+		r.recorder.RecordCachePayload("get", group, len(b))
+	}
+	return b, err
+}
+
+func (r *recordingStore) mget(ctx context.Context, keys []string) ([][]byte, error) {
+	start := time.Now()
+	bs, err := r.store.mget(ctx, keys)
+	r.recorder.RecordCacheOperation("mget", r.group(keys), time.Since(start), errorType(err))
+	for i, b := range bs {
+		if b != nil {
+			r.recorder.RecordCachePayload("mget", keyGroup(r.prefix, keys[i]), len(b))
+		}
+	}
+	return bs, err
+}
+
+func (r *recordingStore) set(ctx context.Context, items ...*storeItem) error {
+	start := time.Now()
+	err := r.store.set(ctx, items...)
+	group := defaultKeyGroup
+	if len(items) > 0 {
+		group = keyGroup(r.prefix, items[0].key)
+	}
+	r.recorder.RecordCacheOperation("set", group, time.Since(start), errorType(err))
+	if err == nil {
+		for _, item := range items {
+			r.recorder.RecordCachePayload("set", keyGroup(r.prefix, item.key), len(item.value))
+		}
+	}
+	return err
+}
+
+func (r *recordingStore) del(ctx context.Context, keys ...string) error {
+	start := time.Now()
+	err := r.store.del(ctx, keys...)
+	r.recorder.RecordCacheOperation("del", r.group(keys), time.Since(start), errorType(err))
+	return err
+}
+
+func (r *recordingStore) invalidateTags(ctx context.Context, tagKeys ...string) ([]string, error) {
+	start := time.Now()
+	keys, err := r.store.invalidateTags(ctx, tagKeys...)
+	r.recorder.RecordCacheOperation("invalidate_tags", tagKeyGroup, time.Since(start), errorType(err))
+	return keys, err
+}
+
+func (r *recordingStore) lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	start := time.Now()
+	unlock, ok, err := r.store.lock(ctx, key, ttl)
+	r.recorder.RecordCacheOperation("lock", keyGroup(r.prefix, key), time.Since(start), errorType(err))
+	return unlock, ok, err
+}
+
+func (r *recordingStore) Stats() *Stats {
+	if reporter, ok := r.store.(StatsReporter); ok {
+		return reporter.Stats()
+	}
+	return &Stats{}
+}
+
+func (r *recordingStore) group(keys []string) string {
+	if len(keys) == 0 {
+		return defaultKeyGroup
+	}
+	return keyGroup(r.prefix, keys[0])
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// testRecorder records operations as "op:group:errType", payloads and evictions.
+type testRecorder struct {
+	mu         sync.Mutex
+	operations []string
+	payloads   map[string]int
+	evictions  map[string]int
+}
+
+func newTestRecorder() *testRecorder {
+	return &testRecorder{payloads: make(map[string]int), evictions: make(map[string]int)}
+}
+
+func (r *testRecorder) RecordCacheOperation(op, group string, _ time.Duration, errType string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations = append(r.operations, fmt.Sprintf("%s:%s:%s", op, group, errType))
+}
+
+func (r *testRecorder) RecordCachePayload(op, group string, size int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payloads[op+":"+group] += size
+}
+
+func (r *testRecorder) RecordCacheEviction(group string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.evictions[group]++
+}
+
+func TestKeyGroup(t *testing.T) {
+	cases := []struct {
+		key      string
+		expected string
+	}{
+		{key: "myapp-user-by-email.v1.user1@email.com", expected: "user-by-email"},
+		{key: "myapp-user-by-id.1", expected: "user-by-id"},
+		{key: "myapp-tag:user", expected: tagKeyGroup},
+		{key: "myapp-key1", expected: defaultKeyGroup},
+		{key: "myapp-.key1", expected: defaultKeyGroup},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.expected, keyGroup("myapp-", tc.key), tc.key)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	assert.Empty(t, errorType(nil))
+	assert.Empty(t, errorType(ErrCacheMiss))
+	assert.Equal(t, "invalid_key", errorType(ErrInvalidKey))
+	assert.Equal(t, "invalid_value", errorType(fmt.Errorf("%w: decode", ErrInvalidValue)))
+	assert.Equal(t, "timeout", errorType(context.DeadlineExceeded))
+	assert.Equal(t, "canceled", errorType(context.Canceled))
+	assert.Equal(t, "other", errorType(errors.New("force err")))
+}
+
+func TestRecordingStore(t *testing.T) {
+	s := miniredis.RunT(t)
+	recorder := newTestRecorder()
+	cacher, err := newRedisCacher(&Config{
+		Prefix: "myapp-",
+		TTL:    time.Minute,
+		Redis:  RedisConfig{Endpoints: []string{s.Addr()}},
+	}, recorder, nil)
+	assert.NoError(t, err)
+	defer cacher.Close()
+
+	assert.NoError(t, cacher.Set(context.TODO(), "user.1", "value1", WithTags("user")))
+	var find string
+	assert.NoError(t, cacher.Get(context.TODO(), "user.1", &find))
+	assert.ErrorIs(t, cacher.Get(context.TODO(), "user.2", &find), ErrCacheMiss)
+	assert.NoError(t, cacher.InvalidateTags(context.TODO(), "user"))
+	s.SetError("force error")
+	assert.Error(t, cacher.Delete(context.TODO(), "user.1"))
+
+	assert.Equal(t, []string{
+		"set:user:",
+		"get:user:",
+		"get:user:",
+		"invalidate_tags:tag:",
+		"del:user:redis",
+	}, recorder.operations)
+	assert.Greater(t, recorder.payloads["set:user"], 0)
+	assert.Equal(t, recorder.payloads["set:user"], recorder.payloads["get:user"])
+	assert.NotNil(t, cacher.(StatsReporter).Stats().Pool)
+}
+
+func TestRecordingStore_Eviction(t *testing.T) {
+	recorder := newTestRecorder()
+	cacher, err := newMemoryCacher(&Config{TTL: time.Minute, Memory: MemoryConfig{Size: 10}}, recorder, nil)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, cacher.Set(context.TODO(), "user.1", "value1"))
+		assert.NoError(t, cacher.Delete(context.TODO(), "user.1"))
+	}
+	assert.Empty(t, recorder.evictions)
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, cacher.Set(context.TODO(), fmt.Sprintf("user.%d", i), "value"))
+	}
+	assert.Greater(t, recorder.evictions["user"], 0)
+}
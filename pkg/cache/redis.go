@@ -2,136 +2,283 @@ package cache
 
 import (
 	"context"
-	"strings"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/go-redis/cache/v8"
 	"github.com/go-redis/redis/v8"
-	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
-var _ Cacher = (*redisCacher)(nil)
+var (
+	_ store         = (*redisStore)(nil)
+	_ StatsReporter = (*redisStore)(nil)
+)
+
+// unlockScript deletes a lock only if it is acquired by the caller.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
 
-func newRedisCacher(conf *Config) (Cacher, error) {
-	cli := openRedisCli(conf)
+// tagScript adds a key to a tag set and extends the set's TTL to be longer than the key's TTL.
+// The set is persisted if the key has no TTL.
+var tagScript = redis.NewScript(`
+local ttl = tonumber(ARGV[2])
+local current = redis.call("PTTL", KEYS[1])
+redis.call("SADD", KEYS[1], ARGV[1])
+if ttl <= 0 then
+	redis.call("PERSIST", KEYS[1])
+elseif current == -2 or (current >= 0 and current < ttl) then
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+return 1
+`)
+
+func newRedisCacher(conf *Config, recorder Recorder, logger *zap.SugaredLogger) (Cacher, error) {
+	codec, err := NewCodec(conf.Codec)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := openRedisCli(conf)
+	if err != nil {
+		return nil, err
+	}
 	// check ping.
 	if err := cli.Ping(context.Background()).Err(); err != nil {
-		logging.DefaultLogger().Infow("failed to ping redis", "err", err)
+		loggerOf(context.Background(), logger).Infow("failed to ping redis", "err", err)
 	} else {
-		logging.DefaultLogger().Info("connected to redis")
+		loggerOf(context.Background(), logger).Info("connected to redis")
+	}
+	r := redisStore{cli: cli, logger: logger}
+	opts := cache.Options{
+		Redis:        cli,
+		StatsEnabled: true,
 	}
-	return &redisCacher{
-		cli: cli,
-		cache: cache.New(&cache.Options{
-			Redis:        cli,
-			StatsEnabled: false,
-		}),
-		prefix: conf.Prefix,
-		ttl:    conf.TTL,
-	}, nil
+	if conf.Local.Enabled {
+		r.local = newLocalCache(conf, logger)
+		opts.LocalCache = r.local
+	}
+	r.cache = cache.New(&opts)
+	if r.local != nil {
+		r.local.subscribe(cli, r.cache)
+	}
+	return newStoreCacher(conf, codec, newRecordingStore(conf.Prefix, &r, recorder), logger), nil
 }
 
-type redisCacher struct {
+// redisStore is a store on redis with optional local cache in front of it.
+type redisStore struct {
 	cli    redis.UniversalClient
 	cache  *cache.Cache
-	prefix string
-	ttl    time.Duration
+	local  *localCache
+	logger *zap.SugaredLogger
 }
 
-func (r *redisCacher) Fetch(ctx context.Context, key string, value interface{}, fetchFunc FetchFunc) error {
-	if key == "" {
-		return ErrInvalidKey
+func (r *redisStore) get(ctx context.Context, key string) ([]byte, error) {
+	var b []byte
+	if err := r.cache.Get(ctx, key, &b); err != nil {
+		return nil, wrapError(err)
+	}
+	return b, nil
+}
+
+func (r *redisStore) mget(ctx context.Context, keys []string) ([][]byte, error) {
+	var (
+		bs   = make([][]byte, len(keys))
+		cmds = make(map[int]*redis.StringCmd)
+	)
+	// uses a pipeline instead of MGET command which requires keys in the same slot of redis cluster.
+	pipe := r.cli.Pipeline()
+	for i, key := range keys {
+		if r.local != nil {
+			if b, ok := r.local.Get(key); ok {
+				bs[i] = b
+				continue
+			}
+		}
+		cmds[i] = pipe.Get(ctx, key)
+	}
+	if len(cmds) == 0 {
+		return bs, nil
 	}
-	item := cache.Item{
-		Ctx:            ctx,
-		Key:            r.computeKey(key),
-		Value:          value,
-		TTL:            r.ttl,
-		SkipLocalCache: true,
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
 	}
-	if fetchFunc != nil {
-		item.Do = func(item *cache.Item) (interface{}, error) {
-			return fetchFunc()
+	for i, cmd := range cmds {
+		b, err := cmd.Bytes()
+		if err != nil {
+			continue
+		}
+		bs[i] = b
+		if r.local != nil {
+			r.local.Set(keys[i], b)
 		}
 	}
-	return r.cache.Once(&item)
+	return bs, nil
 }
 
-func (r *redisCacher) Get(ctx context.Context, key string, value interface{}) error {
-	if key == "" {
-		return ErrInvalidKey
+func (r *redisStore) set(ctx context.Context, items ...*storeItem) error {
+	pipe := r.cli.Pipeline()
+	for _, item := range items {
+		if r.local != nil {
+			r.local.Set(item.key, item.value)
+		}
+		pipe.Set(ctx, item.key, item.value, item.ttl)
+		for _, tagKey := range item.tagKeys {
+			tagScript.Eval(ctx, pipe, []string{tagKey}, item.key, item.ttl.Milliseconds())
+		}
 	}
-	if err := r.cache.Get(ctx, r.computeKey(key), value); err != nil {
-		return r.wrapError(err)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *redisStore) del(ctx context.Context, keys ...string) error {
+	pipe := r.cli.Pipeline()
+	for _, key := range keys {
+		if r.local != nil {
+			r.local.Del(key)
+		}
+		pipe.Del(ctx, key)
 	}
-	return nil
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
-func (r *redisCacher) Set(ctx context.Context, key string, value interface{}) error {
-	if key == "" {
-		return ErrInvalidKey
+func (r *redisStore) invalidateTags(ctx context.Context, tagKeys ...string) ([]string, error) {
+	var keys []string
+	for _, tagKey := range tagKeys {
+		members, err := r.cli.SMembers(ctx, tagKey).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, members...)
 	}
-	err := r.cache.Set(&cache.Item{
-		Ctx:            ctx,
-		Key:            r.computeKey(key),
-		Value:          value,
-		TTL:            r.ttl,
-		SkipLocalCache: true,
-	})
-	if err != nil {
-		return r.wrapError(err)
+	if err := r.del(ctx, append(keys, tagKeys...)...); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// publish publishes given keys to evict local caches of other instances.
+func (r *redisStore) publish(ctx context.Context, keys ...string) {
+	if r.local == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := r.local.publish(ctx, r.cli, key); err != nil {
+			loggerOf(ctx, r.logger).Warnw("failed to publish local cache invalidation", "key", key, "err", err)
+		}
 	}
-	return nil
 }
 
-func (r *redisCacher) Exists(ctx context.Context, key string) (bool, error) {
-	if key == "" {
-		return false, ErrInvalidKey
+func (r *redisStore) lock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	var (
+		lockKey = key + ":lock"
+		token   = uuid.NewString()
+	)
+	ok, err := r.cli.SetNX(ctx, lockKey, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
 	}
-	return r.cache.Exists(ctx, r.computeKey(key)), nil
+	return func() {
+		if err := unlockScript.Run(context.Background(), r.cli, []string{lockKey}, token).Err(); err != nil {
+			loggerOf(context.Background(), r.logger).Warnw("failed to release a cache lock", "key", lockKey, "err", err)
+		}
+	}, true, nil
 }
 
-func (r *redisCacher) Delete(ctx context.Context, key string) error {
-	if key == "" {
-		return ErrInvalidKey
+func (r *redisStore) Stats() *Stats {
+	st := r.cache.Stats()
+	stats := Stats{
+		RedisHits:   st.Hits,
+		RedisMisses: st.Misses,
+		Pool:        r.cli.PoolStats(),
 	}
-	if err := r.cache.Delete(ctx, r.computeKey(key)); err != nil {
-		return r.wrapError(err)
+	if r.local != nil {
+		stats.LocalHits = r.local.hits()
+		// every local miss falls through to redis.
+		stats.LocalMisses = st.Hits + st.Misses
 	}
-	return nil
+	return &stats
+}
+
+func (r *redisStore) redisClient() redis.UniversalClient {
+	return r.cli
 }
 
-func (r *redisCacher) Close() error {
+func (r *redisStore) close() error {
+	if r.local != nil {
+		r.local.close()
+	}
 	if r.cli != nil {
 		return r.cli.Close()
 	}
 	return nil
 }
 
-func (r *redisCacher) computeKey(k string) string {
-	return r.prefix + k
-}
-
-func (r *redisCacher) wrapError(err error) error {
+func wrapError(err error) error {
 	if err == nil {
 		return nil
 	}
 	if err == cache.ErrCacheMiss {
 		return ErrCacheMiss
 	}
+	return err
+}
 
-	msg := err.Error()
-	if strings.Contains(msg, "unknown compression method") {
-		return ErrInvalidValue
+// redisClienter is implemented by Cachers and stores on redis to share the client, e.g. with Locker.
+type redisClienter interface {
+	redisClient() redis.UniversalClient
+}
+
+// redisClientOf returns the redis client of given Cacher or store, or nil if it is not on redis.
+func redisClientOf(v interface{}) redis.UniversalClient {
+	if c, ok := v.(redisClienter); ok {
+		return c.redisClient()
 	}
-	return err
+	return nil
 }
 
-func openRedisCli(conf *Config) redis.UniversalClient {
+func openRedisCli(conf *Config) (redis.UniversalClient, error) {
 	rediscfg := conf.Redis
+	if err := rediscfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid redis config: %w", err)
+	}
+	tlsConfig, err := newRedisTLSConfig(&rediscfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+	if rediscfg.Sentinel.MasterName != "" {
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       rediscfg.Sentinel.MasterName,
+			SentinelAddrs:    rediscfg.Endpoints,
+			SentinelUsername: rediscfg.Sentinel.Username,
+			SentinelPassword: rediscfg.Sentinel.Password,
+			Username:         rediscfg.Username,
+			Password:         rediscfg.Password,
+			DB:               rediscfg.DB,
+			ReadTimeout:      rediscfg.ReadTimeout,
+			WriteTimeout:     rediscfg.WriteTimeout,
+			DialTimeout:      rediscfg.DialTimeout,
+			PoolSize:         rediscfg.PoolSize,
+			PoolTimeout:      rediscfg.PoolTimeout,
+			MaxConnAge:       rediscfg.MaxConnAge,
+			IdleTimeout:      rediscfg.IdleTimeout,
+			TLSConfig:        tlsConfig,
+		}), nil
+	}
 	if !rediscfg.Cluster {
 		return redis.NewClient(&redis.Options{
 			Addr:         rediscfg.Endpoints[0],
+			Username:     rediscfg.Username,
+			Password:     rediscfg.Password,
+			DB:           rediscfg.DB,
 			ReadTimeout:  rediscfg.ReadTimeout,
 			WriteTimeout: rediscfg.WriteTimeout,
 			DialTimeout:  rediscfg.DialTimeout,
@@ -139,10 +286,13 @@ func openRedisCli(conf *Config) redis.UniversalClient {
 			PoolTimeout:  rediscfg.PoolTimeout,
 			MaxConnAge:   rediscfg.MaxConnAge,
 			IdleTimeout:  rediscfg.IdleTimeout,
-		})
+			TLSConfig:    tlsConfig,
+		}), nil
 	}
 	return redis.NewClusterClient(&redis.ClusterOptions{
 		Addrs:         rediscfg.Endpoints,
+		Username:      rediscfg.Username,
+		Password:      rediscfg.Password,
 		ReadTimeout:   rediscfg.ReadTimeout,
 		WriteTimeout:  rediscfg.WriteTimeout,
 		DialTimeout:   rediscfg.DialTimeout,
@@ -150,7 +300,39 @@ func openRedisCli(conf *Config) redis.UniversalClient {
 		PoolTimeout:   rediscfg.PoolTimeout,
 		MaxConnAge:    rediscfg.MaxConnAge,
 		IdleTimeout:   rediscfg.IdleTimeout,
+		TLSConfig:     tlsConfig,
 		ReadOnly:      true, // read on slave nodes.
 		RouteRandomly: true, // read on masster or slave nodes.
-	})
+	}), nil
+}
+
+// newRedisTLSConfig returns a tls.Config from given conf or nil if tls is disabled.
+func newRedisTLSConfig(conf *RedisTLSConfig) (*tls.Config, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+	tlsConfig := tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         conf.ServerName,
+		InsecureSkipVerify: conf.InsecureSkipVerify,
+	}
+	if conf.CAFile != "" {
+		ca, err := os.ReadFile(conf.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read redis ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates in redis ca file: %s", conf.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if conf.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return &tlsConfig, nil
 }
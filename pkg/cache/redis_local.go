@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-redis/cache/v8"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLocalSize    = 10000
+	defaultLocalChannel = "cache-invalidation"
+)
+
+var _ cache.LocalCache = (*localCache)(nil)
+
+// localCache is an in-process cache in front of redis.
+// Keys set or deleted by other instances are evicted through redis pub/sub messages
+// and items are expired after LocalConfig.TTL in case of missing messages.
+type localCache struct {
+	*cache.TinyLFU
+	id      string
+	channel string
+	hitsCnt uint64
+	pubsub  *redis.PubSub
+	done    chan struct{}
+	logger  *zap.SugaredLogger
+}
+
+func newLocalCache(conf *Config, logger *zap.SugaredLogger) *localCache {
+	size := conf.Local.Size
+	if size <= 0 {
+		size = defaultLocalSize
+	}
+	ttl := conf.Local.TTL
+	if ttl <= 0 {
+		ttl = conf.TTL
+	}
+	channel := conf.Local.Channel
+	if channel == "" {
+		channel = defaultLocalChannel
+	}
+	return &localCache{
+		TinyLFU: cache.NewTinyLFU(size, ttl),
+		id:      uuid.NewString(),
+		channel: conf.Prefix + channel,
+		done:    make(chan struct{}),
+		logger:  logger,
+	}
+}
+
+func (l *localCache) Get(key string) ([]byte, bool) {
+	b, ok := l.TinyLFU.Get(key)
+	if ok {
+		atomic.AddUint64(&l.hitsCnt, 1)
+	}
+	return b, ok
+}
+
+func (l *localCache) hits() uint64 {
+	return atomic.LoadUint64(&l.hitsCnt)
+}
+
+// publish broadcasts given key to other instances.
+func (l *localCache) publish(ctx context.Context, cli redis.UniversalClient, key string) error {
+	return cli.Publish(ctx, l.channel, l.id+" "+key).Err()
+}
+
+// subscribe starts to evict keys from given c cache.Cache which are published by other instances.
+func (l *localCache) subscribe(cli redis.UniversalClient, c *cache.Cache) {
+	l.pubsub = cli.Subscribe(context.Background(), l.channel)
+	ch := l.pubsub.Channel()
+	go func() {
+		for {
+			select {
+			case <-l.done:
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				id, key, found := strings.Cut(msg.Payload, " ")
+				if !found {
+					loggerOf(context.Background(), l.logger).Warnw("invalid local cache invalidation message", "payload", msg.Payload)
+					continue
+				}
+				if id == l.id {
+					continue
+				}
+				c.DeleteFromLocalCache(key)
+			}
+		}
+	}()
+}
+
+func (l *localCache) close() {
+	close(l.done)
+	if l.pubsub != nil {
+		if err := l.pubsub.Close(); err != nil {
+			loggerOf(context.Background(), l.logger).Warnw("failed to close local cache subscription", "err", err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LocalRedisCacheSuite struct {
+	suite.Suite
+	mr      *miniredis.Miniredis
+	cacher  Cacher
+	cacher2 Cacher
+}
+
+func TestLocalRedisCache(t *testing.T) {
+	suite.Run(t, new(LocalRedisCacheSuite))
+}
+
+func (s *LocalRedisCacheSuite) SetupSuite() {
+	s.mr = miniredis.RunT(s.T())
+	s.cacher = s.newCacher()
+	s.cacher2 = s.newCacher()
+}
+
+func (s *LocalRedisCacheSuite) TearDownSuite() {
+	s.NoError(s.cacher.Close())
+	s.NoError(s.cacher2.Close())
+	s.mr.Close()
+}
+
+func (s *LocalRedisCacheSuite) newCacher() Cacher {
+	cacher, err := newRedisCacher(&Config{
+		Type: "redis",
+		TTL:  time.Minute,
+		Redis: RedisConfig{
+			Endpoints: []string{s.mr.Addr()},
+		},
+		Local: LocalConfig{
+			Enabled: true,
+			Size:    100,
+			TTL:     time.Minute,
+		},
+	}, nil, nil)
+	s.NoError(err)
+	return cacher
+}
+
+func (s *LocalRedisCacheSuite) TestFetch() {
+	testFetch(s.T(), s.cacher)
+}
+
+func (s *LocalRedisCacheSuite) TestGet() {
+	testGet(s.T(), s.cacher)
+}
+
+func (s *LocalRedisCacheSuite) TestSet() {
+	testSet(s.T(), s.cacher)
+}
+
+func (s *LocalRedisCacheSuite) TestExists() {
+	testExists(s.T(), s.cacher)
+}
+
+func (s *LocalRedisCacheSuite) TestDelete() {
+	testDelete(s.T(), s.cacher)
+}
+
+func (s *LocalRedisCacheSuite) TestMGet() {
+	testMGet(s.T(), s.cacher)
+}
+
+func (s *LocalRedisCacheSuite) TestInvalidateTags() {
+	testInvalidateTags(s.T(), s.cacher)
+}
+
+func (s *LocalRedisCacheSuite) TestLocalHit() {
+	s.NoError(s.cacher.Set(context.TODO(), "local-hit", "value1"))
+	// remove from redis, so only local cache has the item.
+	s.mr.Del("local-hit")
+
+	var find string
+	s.NoError(s.cacher.Get(context.TODO(), "local-hit", &find))
+	s.Equal("value1", find)
+}
+
+func (s *LocalRedisCacheSuite) TestInvalidate() {
+	s.T().Run("Set", func(t *testing.T) {
+		assert.NoError(t, s.cacher.Set(context.TODO(), "invalidate-set", "value1"))
+		var find string
+		assert.NoError(t, s.cacher2.Get(context.TODO(), "invalidate-set", &find))
+		assert.Equal(t, "value1", find)
+
+		assert.NoError(t, s.cacher.Set(context.TODO(), "invalidate-set", "value2"))
+
+		assert.Eventually(t, func() bool {
+			var find string
+			return s.cacher2.Get(context.TODO(), "invalidate-set", &find) == nil && find == "value2"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	s.T().Run("Delete", func(t *testing.T) {
+		assert.NoError(t, s.cacher.Set(context.TODO(), "invalidate-delete", "value1"))
+		var find string
+		assert.NoError(t, s.cacher2.Get(context.TODO(), "invalidate-delete", &find))
+
+		assert.NoError(t, s.cacher.Delete(context.TODO(), "invalidate-delete"))
+
+		assert.Eventually(t, func() bool {
+			ok, err := s.cacher2.Exists(context.TODO(), "invalidate-delete")
+			return err == nil && !ok
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func (s *LocalRedisCacheSuite) TestStats() {
+	cacher := s.newCacher()
+	defer cacher.Close()
+	s.NoError(s.cacher.Set(context.TODO(), "stats", "value1"))
+
+	var find string
+	s.NoError(cacher.Get(context.TODO(), "stats", &find))
+	s.NoError(cacher.Get(context.TODO(), "stats", &find))
+	s.Error(cacher.Get(context.TODO(), "stats-not-exist", &find))
+
+	stats := cacher.(StatsReporter).Stats()
+	s.EqualValues(1, stats.LocalHits)
+	s.EqualValues(2, stats.LocalMisses)
+	s.EqualValues(1, stats.RedisHits)
+	s.EqualValues(1, stats.RedisMisses)
+}
+
+func (s *LocalRedisCacheSuite) TestSet_TTL() {
+	s.NoError(s.cacher.Set(context.TODO(), "set-ttl", "value1", WithTTL(10*time.Second), WithTags("set-ttl-tag")))
+
+	s.Equal(10*time.Second, s.mr.TTL("set-ttl"))
+	s.Equal(10*time.Second, s.mr.TTL("tag:set-ttl-tag"))
+	s.NoError(s.cacher.Set(context.TODO(), "set-ttl2", "value1", WithTTL(20*time.Second), WithTags("set-ttl-tag")))
+	s.Equal(20*time.Second, s.mr.TTL("tag:set-ttl-tag"))
+	s.NoError(s.cacher.Set(context.TODO(), "set-ttl3", "value1", WithTTL(5*time.Second), WithTags("set-ttl-tag")))
+	s.Equal(20*time.Second, s.mr.TTL("tag:set-ttl-tag"))
+}
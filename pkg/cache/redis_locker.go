@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+)
+
+var _ Locker = (*redisLocker)(nil)
+
+// acquireScript sets a lock if not exists and returns a fencing token increased from the last one.
+// Returns 0 if the lock is acquired by others.
+var acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+// extendScript extends a lock only if it is acquired by the caller.
+var extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// redisLocker is a Locker with SET NX on redis.
+type redisLocker struct {
+	cli           redis.UniversalClient
+	prefix        string
+	retryInterval time.Duration
+}
+
+func newRedisLocker(conf *Config, cli redis.UniversalClient) *redisLocker {
+	return &redisLocker{
+		cli:           cli,
+		prefix:        conf.Prefix,
+		retryInterval: conf.Locker.RetryInterval,
+	}
+}
+
+func (r *redisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+	var (
+		// hash tag keeps the lock and the fencing token in the same slot on cluster.
+		lockKey  = fmt.Sprintf("%slock:{%s}", r.prefix, key)
+		fenceKey = lockKey + ":fence"
+		owner    = uuid.NewString()
+	)
+	token, err := acquireScript.Run(ctx, r.cli, []string{lockKey, fenceKey}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if token == 0 {
+		return nil, ErrLockNotAcquired
+	}
+	return &redisLock{cli: r.cli, key: key, lockKey: lockKey, owner: owner, token: token}, nil
+}
+
+func (r *redisLocker) Lock(ctx context.Context, key string, ttl time.Duration) (Lock, error) {
+	return lockWithRetry(ctx, r.TryLock, r.retryInterval, key, ttl)
+}
+
+// Close does nothing since the client is closed by the Cacher sharing it.
+func (r *redisLocker) Close() error {
+	return nil
+}
+
+type redisLock struct {
+	cli     redis.UniversalClient
+	key     string
+	lockKey string
+	owner   string
+	token   int64
+}
+
+func (l *redisLock) Key() string {
+	return l.key
+}
+
+func (l *redisLock) Token() int64 {
+	return l.token
+}
+
+func (l *redisLock) Extend(ctx context.Context, ttl time.Duration) error {
+	ok, err := extendScript.Run(ctx, l.cli, []string{l.lockKey}, l.owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+func (l *redisLock) Release(ctx context.Context) error {
+	ok, err := unlockScript.Run(ctx, l.cli, []string{l.lockKey}, l.owner).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
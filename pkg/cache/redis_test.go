@@ -1,8 +1,12 @@
 package cache
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -50,3 +54,121 @@ func (s *RedisCacheSuite) TestExists() {
 func (s *RedisCacheSuite) TestDelete() {
 	testDelete(s.T(), s.cacher)
 }
+
+func (s *RedisCacheSuite) TestMGet() {
+	testMGet(s.T(), s.cacher)
+}
+
+func (s *RedisCacheSuite) TestInvalidateTags() {
+	testInvalidateTags(s.T(), s.cacher)
+}
+
+func TestRedisConfig_Validate(t *testing.T) {
+	cases := []struct {
+		name   string
+		update func(conf *RedisConfig)
+		errs   []string
+	}{
+		{name: "Valid", update: func(conf *RedisConfig) {}},
+		{
+			name:   "Empty Endpoints",
+			update: func(conf *RedisConfig) { conf.Endpoints = nil },
+			errs:   []string{"require at least one endpoint"},
+		},
+		{
+			name: "Cluster With DB And Sentinel",
+			update: func(conf *RedisConfig) {
+				conf.Cluster = true
+				conf.DB = 1
+				conf.Sentinel.MasterName = "master"
+			},
+			errs: []string{"db must be zero on cluster", "sentinel is not supported on cluster"},
+		},
+		{
+			name:   "Username Without Password",
+			update: func(conf *RedisConfig) { conf.Username = "user" },
+			errs:   []string{"require password with username"},
+		},
+		{
+			name:   "TLS Cert Without Key",
+			update: func(conf *RedisConfig) { conf.TLS.CertFile = "cert.pem" },
+			errs:   []string{"tls files are given but tls is disabled", "require both tls cert-file and key-file"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := RedisConfig{Endpoints: []string{"localhost:6379"}}
+			tc.update(&conf)
+
+			err := conf.Validate()
+
+			if len(tc.errs) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			for _, msg := range tc.errs {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+func TestOpenRedisCli(t *testing.T) {
+	s := miniredis.RunT(t)
+	s.RequireUserAuth("user1", "pass1")
+
+	t.Run("Auth And DB", func(t *testing.T) {
+		cacher, err := newRedisCacher(&Config{
+			TTL: time.Minute,
+			Redis: RedisConfig{
+				Endpoints: []string{s.Addr()},
+				Username:  "user1",
+				Password:  "pass1",
+				DB:        2,
+			},
+		}, nil, nil)
+		assert.NoError(t, err)
+		defer cacher.Close()
+
+		assert.NoError(t, cacher.Set(context.TODO(), "key1", "value1"))
+		assert.True(t, s.DB(2).Exists("key1"))
+	})
+
+	t.Run("Invalid Password", func(t *testing.T) {
+		cacher, err := newRedisCacher(&Config{
+			TTL: time.Minute,
+			Redis: RedisConfig{
+				Endpoints: []string{s.Addr()},
+				Username:  "user1",
+				Password:  "invalid",
+			},
+		}, nil, nil)
+		assert.NoError(t, err)
+		defer cacher.Close()
+
+		assert.Error(t, cacher.Set(context.TODO(), "key1", "value1"))
+	})
+
+	t.Run("Invalid Config", func(t *testing.T) {
+		cacher, err := newRedisCacher(&Config{TTL: time.Minute}, nil, nil)
+
+		assert.Nil(t, cacher)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "require at least one endpoint")
+	})
+
+	t.Run("Invalid TLS CA File", func(t *testing.T) {
+		cacher, err := newRedisCacher(&Config{
+			TTL: time.Minute,
+			Redis: RedisConfig{
+				Endpoints: []string{s.Addr()},
+				TLS:       RedisTLSConfig{Enabled: true, CAFile: "not-exist.pem"},
+			},
+		}, nil, nil)
+
+		assert.Nil(t, cacher)
+		assert.Error(t, err)
+	})
+}
@@ -17,14 +17,16 @@ import (
 func NewTestMemoryRedisCacher(tb testing.TB) (Cacher, CloseFn, error) {
 	s := miniredis.RunT(tb)
 	conf := Config{
-		Type: "redis",
-		TTL:  time.Minute,
+		Type:        "redis",
+		TTL:         time.Minute,
+		NegativeTTL: 5 * time.Second,
+		LockTTL:     time.Second,
 		Redis: RedisConfig{
 			Cluster:   false,
 			Endpoints: []string{s.Addr()},
 		},
 	}
-	cacher, err := newRedisCacher(&conf)
+	cacher, err := newRedisCacher(&conf, nil, nil)
 	if err != nil {
 		tb.Fatalf("failed to create a new redis cacher. err: %v", err)
 	}
@@ -72,7 +74,7 @@ func NewTestRedisCacher(tb testing.TB) (Cacher, CloseFn, error) {
 			Endpoints: []string{fmt.Sprintf("localhost:%s", resource.GetPort("6379/tcp"))},
 		},
 	}
-	cacher, err := newRedisCacher(&conf)
+	cacher, err := newRedisCacher(&conf, nil, nil)
 	if err != nil {
 		tb.Fatalf("failed to create a new redis cacher. err: %v", err)
 	}
@@ -134,7 +136,11 @@ func NewTestClusterRedisCacher(tb testing.TB) (Cacher, CloseFn, error) {
 			},
 		},
 	}
-	cli := openRedisCli(&conf)
+	cli, err := openRedisCli(&conf)
+	if err != nil {
+		closeFn()
+		tb.Fatalf("failed to open redis cluster client: %v", err)
+	}
 	defer cli.Close()
 
 	if err := pool.Retry(func() error {
@@ -166,7 +172,7 @@ func NewTestClusterRedisCacher(tb testing.TB) (Cacher, CloseFn, error) {
 		tb.Fatalf("failed to connect to redis clusters. err: %v", err)
 	}
 
-	cacher, err := newRedisCacher(&conf)
+	cacher, err := newRedisCacher(&conf, nil, nil)
 	if err != nil {
 		tb.Fatalf("failed to create a new redis cacher. err: %v", err)
 	}
@@ -0,0 +1,531 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+const lockRetryInterval = 50 * time.Millisecond
+
+// store is a storage of encoded items used by storeCacher.
+type store interface {
+	// get returns an item for the given key if exists, otherwise ErrCacheMiss.
+	get(ctx context.Context, key string) ([]byte, error)
+
+	// mget returns items for the given keys in the same order. Missing items are nil.
+	mget(ctx context.Context, keys []string) ([][]byte, error)
+
+	// set adds given items.
+	set(ctx context.Context, items ...*storeItem) error
+
+	// del removes items of given keys.
+	del(ctx context.Context, keys ...string) error
+
+	// invalidateTags removes items associated with given tag keys and returns keys of the removed items.
+	invalidateTags(ctx context.Context, tagKeys ...string) ([]string, error)
+
+	// publish notifies other instances that items of given keys have been changed.
+	publish(ctx context.Context, keys ...string)
+
+	// lock acquires a lock of given key for ttl. ok is false if the lock is already acquired by others.
+	// The returned unlock is nil if the store does not support locking.
+	lock(ctx context.Context, key string, ttl time.Duration) (unlock func(), ok bool, err error)
+
+	close() error
+}
+
+// storeItem is an encoded item expired after ttl and associated with tag keys.
+type storeItem struct {
+	key     string
+	value   []byte
+	ttl     time.Duration
+	tagKeys []string
+}
+
+// entry is an envelope of a cached item.
+type entry struct {
+	// Value is an encoded value of the item.
+	Value []byte `msgpack:"v,omitempty"`
+	// StaleAt is an unix nano time after which the item should be fetched again. Zero if never.
+	StaleAt int64 `msgpack:"s,omitempty"`
+	// NotFound is true if the item is a negative result.
+	NotFound bool `msgpack:"n,omitempty"`
+	// Codec is a name of the Codec encoded the Value.
+	Codec string `msgpack:"c,omitempty"`
+}
+
+func (e *entry) stale(now time.Time) bool {
+	return e.StaleAt != 0 && now.UnixNano() > e.StaleAt
+}
+
+var (
+	_ Cacher        = (*storeCacher)(nil)
+	_ StatsReporter = (*storeCacher)(nil)
+)
+
+// storeCacher is a Cacher which stores encoded items to a store.
+type storeCacher struct {
+	store       store
+	codec       Codec
+	prefix      string
+	ttl         time.Duration
+	ttlJitter   float64
+	negativeTTL time.Duration
+	lockTTL     time.Duration
+	loadTimeout time.Duration
+	logger      *zap.SugaredLogger
+
+	group        singleflight.Group
+	refreshing   sync.Map
+	randMu       sync.Mutex
+	rand         *rand.Rand
+	decodeErrors uint64
+}
+
+func newStoreCacher(conf *Config, codec Codec, s store, logger *zap.SugaredLogger) *storeCacher {
+	return &storeCacher{
+		store:       s,
+		codec:       codec,
+		prefix:      conf.Prefix,
+		ttl:         conf.TTL,
+		ttlJitter:   conf.TTLJitter,
+		negativeTTL: conf.NegativeTTL,
+		lockTTL:     conf.LockTTL,
+		loadTimeout: conf.LoadTimeout,
+		logger:      logger,
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (c *storeCacher) Fetch(ctx context.Context, key string, value interface{}, fetchFunc FetchFunc, opts ...Option) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	var (
+		k = c.computeKey(key)
+		o = newOptions(opts...)
+	)
+	e, err := c.getEntry(ctx, k)
+	if err != nil && err != ErrCacheMiss {
+		return err
+	}
+	if e != nil && e.NotFound && o.negativeErr == nil {
+		e = nil
+	}
+	if e != nil && !e.NotFound {
+		err := c.decodeCached(ctx, k, e.Value, value)
+		if err == nil {
+			if e.stale(time.Now()) && fetchFunc != nil {
+				c.refresh(k, fetchFunc, o)
+			}
+			return nil
+		}
+		if err != ErrCacheMiss {
+			return err
+		}
+		e = nil
+	}
+	if e == nil {
+		if fetchFunc == nil {
+			return ErrCacheMiss
+		}
+		// loads without the ctx of the first caller, so others are not failed when it is cancelled.
+		ch := c.group.DoChan(k, func() (interface{}, error) {
+			loadCtx, cancel := c.loadContext(ctx)
+			defer cancel()
+			return c.load(loadCtx, k, fetchFunc, o)
+		})
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case res := <-ch:
+			if res.Err != nil {
+				return res.Err
+			}
+			e = res.Val.(*entry)
+		}
+	}
+	if e.NotFound {
+		return o.negativeErr
+	}
+	return c.decode(e.Value, value)
+}
+
+func (c *storeCacher) Get(ctx context.Context, key string, value interface{}) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	k := c.computeKey(key)
+	e, err := c.getEntry(ctx, k)
+	if err != nil {
+		return err
+	}
+	if e.NotFound {
+		return ErrCacheMiss
+	}
+	return c.decodeCached(ctx, k, e.Value, value)
+}
+
+func (c *storeCacher) Set(ctx context.Context, key string, value interface{}, opts ...Option) error {
+	return c.MSet(ctx, map[string]interface{}{key: value}, opts...)
+}
+
+func (c *storeCacher) MGet(ctx context.Context, keys []string, values []interface{}) ([]string, error) {
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("%w: %d values for %d keys", ErrInvalidValue, len(values), len(keys))
+	}
+	ks := make([]string, len(keys))
+	for i, key := range keys {
+		if !validKey(key) {
+			return nil, ErrInvalidKey
+		}
+		ks[i] = c.computeKey(key)
+	}
+	bs, err := c.store.mget(ctx, ks)
+	if err != nil {
+		return nil, err
+	}
+	var missing []string
+	for i, b := range bs {
+		e := c.decodeEntry(ctx, ks[i], b)
+		if e == nil || e.NotFound {
+			missing = append(missing, keys[i])
+			continue
+		}
+		if err := c.decodeCached(ctx, ks[i], e.Value, values[i]); err != nil {
+			if err != ErrCacheMiss {
+				return nil, err
+			}
+			missing = append(missing, keys[i])
+		}
+	}
+	return missing, nil
+}
+
+func (c *storeCacher) MSet(ctx context.Context, items map[string]interface{}, opts ...Option) error {
+	var (
+		o       = newOptions(opts...)
+		ks      = make([]string, 0, len(items))
+		entries = make([]*storeItem, 0, len(items))
+	)
+	for key, value := range items {
+		if !validKey(key) {
+			return ErrInvalidKey
+		}
+		b, err := c.codec.Marshal(value)
+		if err != nil {
+			return err
+		}
+		item, err := c.newStoreItem(c.computeKey(key), &entry{Value: b, Codec: c.codec.Name()}, c.itemTTL(o), o)
+		if err != nil {
+			return err
+		}
+		ks = append(ks, item.key)
+		entries = append(entries, item)
+	}
+	if err := c.store.set(ctx, entries...); err != nil {
+		return err
+	}
+	c.store.publish(ctx, ks...)
+	return nil
+}
+
+func (c *storeCacher) Exists(ctx context.Context, key string) (bool, error) {
+	if !validKey(key) {
+		return false, ErrInvalidKey
+	}
+	e, err := c.getEntry(ctx, c.computeKey(key))
+	if err != nil {
+		if err == ErrCacheMiss {
+			return false, nil
+		}
+		return false, err
+	}
+	return !e.NotFound, nil
+}
+
+func (c *storeCacher) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	k := c.computeKey(key)
+	if err := c.store.del(ctx, k); err != nil {
+		return err
+	}
+	c.store.publish(ctx, k)
+	return nil
+}
+
+func (c *storeCacher) InvalidateTags(ctx context.Context, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	ks, err := c.store.invalidateTags(ctx, c.tagKeys(tags)...)
+	if err != nil {
+		return err
+	}
+	c.store.publish(ctx, ks...)
+	return nil
+}
+
+func (c *storeCacher) Stats() *Stats {
+	stats := &Stats{}
+	if reporter, ok := c.store.(StatsReporter); ok {
+		stats = reporter.Stats()
+	}
+	stats.DecodeErrors = atomic.LoadUint64(&c.decodeErrors)
+	return stats
+}
+
+func (c *storeCacher) Close() error {
+	return c.store.close()
+}
+
+func (c *storeCacher) redisClient() redis.UniversalClient {
+	return redisClientOf(c.store)
+}
+
+// load calls given fetchFunc and caches the result.
+// If o has lock option, waits for the result cached by others while the lock is acquired by others.
+func (c *storeCacher) load(ctx context.Context, k string, fetchFunc FetchFunc, o *options) (*entry, error) {
+	if o.lock && c.lockTTL > 0 {
+		unlock, ok, err := c.store.lock(ctx, k, c.lockTTL)
+		if err != nil {
+			loggerOf(ctx, c.logger).Warnw("failed to acquire a cache lock", "key", k, "err", err)
+		}
+		if unlock != nil {
+			defer unlock()
+		}
+		if err == nil && !ok {
+			if e := c.waitEntry(ctx, k, c.lockTTL); e != nil {
+				return e, nil
+			}
+		}
+	}
+
+	v, err := fetchFunc(ctx)
+	if err != nil {
+		if o.negativeErr == nil || c.negativeTTL <= 0 || !errors.Is(err, o.negativeErr) {
+			return nil, err
+		}
+		e := entry{NotFound: true}
+		if err := c.setEntry(ctx, k, &e, c.jitter(c.negativeTTL), o); err != nil {
+			loggerOf(ctx, c.logger).Warnw("failed to cache a negative result", "key", k, "err", err)
+		}
+		return &e, nil
+	}
+
+	b, err := c.codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		e   = entry{Value: b, Codec: c.codec.Name()}
+		ttl = c.itemTTL(o)
+	)
+	if o.staleTTL > 0 {
+		e.StaleAt = time.Now().Add(ttl).UnixNano()
+		ttl += o.staleTTL
+	}
+	if err := c.setEntry(ctx, k, &e, ttl, o); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// refresh loads an item of given k key in background if not being loaded.
+func (c *storeCacher) refresh(k string, fetchFunc FetchFunc, o *options) {
+	if _, loaded := c.refreshing.LoadOrStore(k, struct{}{}); loaded {
+		return
+	}
+	go func() {
+		defer c.refreshing.Delete(k)
+		ctx, cancel := c.loadContext(context.Background())
+		defer cancel()
+		if o.lock && c.lockTTL > 0 {
+			unlock, ok, err := c.store.lock(ctx, k, c.lockTTL)
+			if err != nil || !ok {
+				// others are refreshing.
+				return
+			}
+			if unlock != nil {
+				defer unlock()
+			}
+		}
+		refreshOpts := *o
+		refreshOpts.lock = false
+		if _, err := c.load(ctx, k, fetchFunc, &refreshOpts); err != nil {
+			loggerOf(ctx, c.logger).Warnw("failed to refresh a stale item", "key", k, "err", err)
+			return
+		}
+		c.store.publish(ctx, k)
+	}()
+}
+
+// loadContext returns a context to load an item which keeps values of given ctx but is not cancelled with it.
+func (c *storeCacher) loadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = detachedContext{parent: ctx}
+	if c.loadTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.loadTimeout)
+}
+
+// detachedContext is a context.Context never cancelled which keeps values of the parent.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// waitEntry waits for an item of given k key to be cached until timeout.
+// Returns nil if the item is not cached within the timeout.
+func (c *storeCacher) waitEntry(ctx context.Context, k string, timeout time.Duration) *entry {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(lockRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			return nil
+		case <-ticker.C:
+			if e, err := c.getEntry(ctx, k); err == nil {
+				return e
+			}
+		}
+	}
+}
+
+func (c *storeCacher) getEntry(ctx context.Context, k string) (*entry, error) {
+	b, err := c.store.get(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	e := c.decodeEntry(ctx, k, b)
+	if e == nil {
+		return nil, ErrCacheMiss
+	}
+	return e, nil
+}
+
+// decodeEntry returns an entry decoded from given b or nil if not decodable,
+// e.g. written in an old format or by other codecs.
+func (c *storeCacher) decodeEntry(ctx context.Context, k string, b []byte) *entry {
+	if b == nil {
+		return nil
+	}
+	var e entry
+	if err := msgpack.Unmarshal(b, &e); err != nil {
+		c.decodeFailed(ctx, k, err)
+		return nil
+	}
+	if !e.NotFound && e.Codec != c.codec.Name() {
+		c.decodeFailed(ctx, k, fmt.Errorf("encoded by unknown codec: %q", e.Codec))
+		return nil
+	}
+	return &e
+}
+
+func (c *storeCacher) setEntry(ctx context.Context, k string, e *entry, ttl time.Duration, o *options) error {
+	item, err := c.newStoreItem(k, e, ttl, o)
+	if err != nil {
+		return err
+	}
+	return c.store.set(ctx, item)
+}
+
+func (c *storeCacher) newStoreItem(k string, e *entry, ttl time.Duration, o *options) (*storeItem, error) {
+	b, err := msgpack.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return &storeItem{key: k, value: b, ttl: ttl, tagKeys: c.tagKeys(o.tags)}, nil
+}
+
+// decode decodes given b to value which must be a non-nil pointer.
+func (c *storeCacher) decode(b []byte, value interface{}) error {
+	if rv := reflect.ValueOf(value); rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrInvalidValue
+	}
+	if err := c.codec.Unmarshal(b, value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+	return nil
+}
+
+// decodeCached decodes a cached b to value. Returns ErrCacheMiss and resets the value
+// if the b is not decodable, e.g. cached by other versions of the application.
+func (c *storeCacher) decodeCached(ctx context.Context, k string, b []byte, value interface{}) error {
+	err := c.decode(b, value)
+	if err == nil || err == ErrInvalidValue {
+		return err
+	}
+	c.decodeFailed(ctx, k, err)
+	rv := reflect.ValueOf(value).Elem()
+	rv.Set(reflect.Zero(rv.Type()))
+	return ErrCacheMiss
+}
+
+func (c *storeCacher) decodeFailed(ctx context.Context, k string, err error) {
+	atomic.AddUint64(&c.decodeErrors, 1)
+	loggerOf(ctx, c.logger).Warnw("failed to decode a cached item", "key", k, "err", err)
+}
+
+// itemTTL returns a TTL of an item with given o options applied jitter.
+func (c *storeCacher) itemTTL(o *options) time.Duration {
+	if o.ttl > 0 {
+		return c.jitter(o.ttl)
+	}
+	return c.jitter(c.ttl)
+}
+
+// jitter returns given ttl increased randomly up to ttlJitter ratio.
+func (c *storeCacher) jitter(ttl time.Duration) time.Duration {
+	if c.ttlJitter <= 0 || ttl <= 0 {
+		return ttl
+	}
+	c.randMu.Lock()
+	defer c.randMu.Unlock()
+	return ttl + time.Duration(c.rand.Float64()*c.ttlJitter*float64(ttl))
+}
+
+// validKey returns false if given key is empty or starts with tagKeyPrefix reserved for keys of tags.
+func validKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, tagKeyPrefix)
+}
+
+func (c *storeCacher) tagKeys(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	ks := make([]string, len(tags))
+	for i, tag := range tags {
+		ks[i] = c.prefix + tagKeyPrefix + tag
+	}
+	return ks
+}
+
+func (c *storeCacher) computeKey(k string) string {
+	return c.prefix + k
+}
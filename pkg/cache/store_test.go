@@ -0,0 +1,239 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTestNotFound = errors.New("not found")
+
+func TestFetch_Negative(t *testing.T) {
+	cacher, err := newMemoryCacher(&Config{TTL: time.Minute, NegativeTTL: 100 * time.Millisecond}, nil, nil)
+	assert.NoError(t, err)
+	var calls int32
+	notFound := func(context.Context) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errTestNotFound
+	}
+
+	var find string
+	err = cacher.Fetch(context.TODO(), "negative", &find, notFound, WithNegative(errTestNotFound))
+	assert.ErrorIs(t, err, errTestNotFound)
+	err = cacher.Fetch(context.TODO(), "negative", &find, notFound, WithNegative(errTestNotFound))
+	assert.ErrorIs(t, err, errTestNotFound)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	assert.ErrorIs(t, cacher.Get(context.TODO(), "negative", &find), ErrCacheMiss)
+	ok, err := cacher.Exists(context.TODO(), "negative")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	t.Run("Without Option", func(t *testing.T) {
+		err := cacher.Fetch(context.TODO(), "negative", &find, func(context.Context) (interface{}, error) {
+			return "value1", nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "value1", find)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		assert.NoError(t, cacher.Delete(context.TODO(), "negative"))
+		assert.ErrorIs(t, cacher.Fetch(context.TODO(), "negative", &find, notFound, WithNegative(errTestNotFound)), errTestNotFound)
+		calls = 0
+
+		time.Sleep(200 * time.Millisecond)
+
+		assert.ErrorIs(t, cacher.Fetch(context.TODO(), "negative", &find, notFound, WithNegative(errTestNotFound)), errTestNotFound)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Other Error", func(t *testing.T) {
+		err := cacher.Fetch(context.TODO(), "negative-other", &find, func(context.Context) (interface{}, error) {
+			return nil, errors.New("force err")
+		}, WithNegative(errTestNotFound))
+		assert.Error(t, err)
+
+		ok, err := cacher.Exists(context.TODO(), "negative-other")
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestFetch_Stale(t *testing.T) {
+	cacher, err := newMemoryCacher(&Config{TTL: 100 * time.Millisecond}, nil, nil)
+	assert.NoError(t, err)
+	var value atomic.Value
+	value.Store("value1")
+	fetchFunc := func(context.Context) (interface{}, error) {
+		return value.Load(), nil
+	}
+	var find string
+	assert.NoError(t, cacher.Fetch(context.TODO(), "stale", &find, fetchFunc, WithStale(time.Minute)))
+	value.Store("value2")
+
+	time.Sleep(200 * time.Millisecond)
+
+	assert.NoError(t, cacher.Fetch(context.TODO(), "stale", &find, fetchFunc, WithStale(time.Minute)))
+	assert.Equal(t, "value1", find)
+	assert.Eventually(t, func() bool {
+		var find string
+		return cacher.Get(context.TODO(), "stale", &find) == nil && find == "value2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestFetch_Lock(t *testing.T) {
+	s := miniredis.RunT(t)
+	var cachers []Cacher
+	for i := 0; i < 3; i++ {
+		cacher, err := newRedisCacher(&Config{
+			TTL:     time.Minute,
+			LockTTL: time.Second,
+			Redis:   RedisConfig{Endpoints: []string{s.Addr()}},
+		}, nil, nil)
+		assert.NoError(t, err)
+		defer cacher.Close()
+		cachers = append(cachers, cacher)
+	}
+
+	var (
+		wg    sync.WaitGroup
+		calls int32
+	)
+	for _, cacher := range cachers {
+		wg.Add(1)
+		go func(cacher Cacher) {
+			defer wg.Done()
+			var find string
+			err := cacher.Fetch(context.TODO(), "lock", &find, func(context.Context) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(200 * time.Millisecond)
+				return "value1", nil
+			}, WithLock())
+			assert.NoError(t, err)
+			assert.Equal(t, "value1", find)
+		}(cacher)
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	assert.False(t, s.Exists("lock:lock"))
+}
+
+func TestFetch_DecodeFailure(t *testing.T) {
+	s := miniredis.RunT(t)
+	newCacher := func(codec string) *storeCacher {
+		cacher, err := newRedisCacher(&Config{
+			Codec: codec,
+			TTL:   time.Minute,
+			Redis: RedisConfig{Endpoints: []string{s.Addr()}},
+		}, nil, nil)
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = cacher.Close() })
+		return cacher.(*storeCacher)
+	}
+	var (
+		jsonCacher    = newCacher(CodecJSON)
+		msgpackCacher = newCacher(CodecMsgpack)
+		fetchFunc     = func(context.Context) (interface{}, error) {
+			return &testCodecItem{Name: "fetched"}, nil
+		}
+	)
+
+	t.Run("Other Codec", func(t *testing.T) {
+		assert.NoError(t, jsonCacher.Set(context.TODO(), "codec", &testCodecItem{Name: "cached"}))
+
+		var find testCodecItem
+		assert.ErrorIs(t, msgpackCacher.Get(context.TODO(), "codec", &find), ErrCacheMiss)
+		assert.NoError(t, msgpackCacher.Fetch(context.TODO(), "codec", &find, fetchFunc))
+		assert.Equal(t, "fetched", find.Name)
+		assert.EqualValues(t, 2, msgpackCacher.Stats().DecodeErrors)
+	})
+
+	t.Run("Other Schema", func(t *testing.T) {
+		assert.NoError(t, jsonCacher.Set(context.TODO(), "schema", []string{"cached"}))
+
+		find := testCodecItem{Tags: []string{"garbage"}}
+		assert.NoError(t, jsonCacher.Fetch(context.TODO(), "schema", &find, fetchFunc))
+		assert.Equal(t, testCodecItem{Name: "fetched"}, find)
+		missing, err := jsonCacher.MGet(context.TODO(), []string{"schema"}, []interface{}{new(testCodecItem)})
+		assert.NoError(t, err)
+		assert.Empty(t, missing)
+		assert.EqualValues(t, 1, jsonCacher.Stats().DecodeErrors)
+	})
+
+	t.Run("Corrupted", func(t *testing.T) {
+		assert.NoError(t, s.Set("corrupted", "garbage"))
+
+		var find testCodecItem
+		missing, err := msgpackCacher.MGet(context.TODO(), []string{"corrupted"}, []interface{}{&find})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"corrupted"}, missing)
+	})
+}
+
+func TestFetch_CancelFirstCaller(t *testing.T) {
+	cacher, err := newMemoryCacher(&Config{TTL: time.Minute, LoadTimeout: time.Second}, nil, nil)
+	assert.NoError(t, err)
+	var (
+		started  = make(chan struct{})
+		release  = make(chan struct{})
+		loadErrs = make(chan error, 1)
+	)
+	fetchFunc := func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		loadErrs <- ctx.Err()
+		return "value1", nil
+	}
+
+	// the first caller is cancelled while loading.
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		firstErr <- cacher.Fetch(ctx, "key1", new(string), fetchFunc)
+	}()
+	<-started
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+
+	// others wait for the same load.
+	secondErr := make(chan error, 1)
+	var find string
+	go func() {
+		secondErr <- cacher.Fetch(context.TODO(), "key1", &find, fetchFunc)
+	}()
+	close(release)
+	assert.NoError(t, <-secondErr)
+	assert.Equal(t, "value1", find)
+	assert.NoError(t, <-loadErrs)
+	assert.Empty(t, loadErrs)
+
+	t.Run("Load Timeout", func(t *testing.T) {
+		cacher, err := newMemoryCacher(&Config{TTL: time.Minute, LoadTimeout: 10 * time.Millisecond}, nil, nil)
+		assert.NoError(t, err)
+
+		err = cacher.Fetch(context.TODO(), "key1", new(string), func(ctx context.Context) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestJitter(t *testing.T) {
+	cacher := newStoreCacher(&Config{TTL: time.Minute, TTLJitter: 0.1}, jsonCodec{}, &memoryStore{}, nil)
+
+	for i := 0; i < 100; i++ {
+		ttl := cacher.jitter(time.Minute)
+		assert.GreaterOrEqual(t, ttl, time.Minute)
+		assert.Less(t, ttl, time.Minute+6*time.Second)
+	}
+}
@@ -1,8 +1,13 @@
 package cfgloader
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/knadh/koanf"
@@ -31,7 +36,7 @@ func LoadWithOptions(conf interface{}, defaultConf map[string]interface{}, opts
 			return nil, err
 		}
 	}
-	if err := k.UnmarshalWithConf("", conf, koanf.UnmarshalConf{Tag: "json", FlatPaths: false}); err != nil {
+	if err := unmarshal(k, conf); err != nil {
 		return nil, err
 	}
 	return k, nil
@@ -68,8 +73,31 @@ func WithConfigFile(configFile string) Option {
 	}
 }
 
+// ConfigFiles returns config files with supported extensions in given dir sorted by names.
+// Returns nil if the dir does not exist.
+func ConfigFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		switch filepath.Ext(e.Name()) {
+		case ".yaml", ".yml", ".json":
+			if !e.IsDir() {
+				files = append(files, filepath.Join(dir, e.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
 // WithEnv loads config from environments.
 // Use Kebab case for proper parsing.
+// A key with "_FILE" suffix is a reference to a file containing the value of the key without the suffix.
 // For example, The env value "{PREFIX}SERVER_PORT=8080" will be parsed to "server.port=8080".
 // "{PREFIX}SERVER_READ-TIMEOUT=5m" to "server.read-timeout=5m".
 func WithEnv(prefix string) Option {
@@ -79,6 +107,10 @@ func WithEnv(prefix string) Option {
 			key = strings.ToLower(strings.TrimPrefix(key, prefix))
 			// replace "_" to "."
 			key = strings.ReplaceAll(key, "_", ".")
+			// "{PREFIX}SERVER_AUTH_JWT_KEY_FILE=/run/secrets/jwt-key" is a reference to a file of "server.auth.jwt.key".
+			if strings.HasSuffix(key, fileSuffix) {
+				return strings.TrimSuffix(key, fileSuffix), fileRefPrefix + value
+			}
 			// if value is array type, then split with "," separator.
 			switch k.Get(key).(type) {
 			case []interface{}, []string:
@@ -94,8 +126,98 @@ func Override(k *koanf.Koanf, conf interface{}, newValues map[string]interface{}
 	if err := k.Load(confmap.Provider(newValues, "."), nil); err != nil {
 		return nil, err
 	}
-	if err := k.UnmarshalWithConf("", conf, koanf.UnmarshalConf{Tag: "json", FlatPaths: false}); err != nil {
+	if err := unmarshal(k, conf); err != nil {
 		return nil, err
 	}
 	return k, nil
 }
+
+// Reload unmarshals given k to conf again with secret references resolved, so rotated file secrets are applied.
+func Reload(k *koanf.Koanf, conf interface{}) error {
+	return unmarshal(k, conf)
+}
+
+// unmarshal unmarshals given k to conf with resolved secret references.
+// The k keeps the references to resolve them again on reload.
+func unmarshal(k *koanf.Koanf, conf interface{}) error {
+	resolved, err := resolveSecrets(k)
+	if err != nil {
+		return err
+	}
+	return resolved.UnmarshalWithConf("", conf, koanf.UnmarshalConf{Tag: "json", FlatPaths: false})
+}
+
+// UnknownKeys returns keys loaded in given k which are not matched with json tags of given conf's fields.
+// All keys under a map field are known.
+func UnknownKeys(k *koanf.Koanf, conf interface{}) []string {
+	var (
+		known    = make(map[string]struct{})
+		prefixes []string
+	)
+	collectKeys(reflect.TypeOf(conf), "", known, &prefixes)
+
+	var unknown []string
+	for _, key := range k.Keys() {
+		if _, ok := known[key]; ok || hasAnyPrefix(key, prefixes) {
+			continue
+		}
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// collectKeys collects keys of leaf fields of given t to known and keys of map fields to prefixes.
+func collectKeys(t reflect.Type, path string, known map[string]struct{}, prefixes *[]string) {
+	walkKeys(t, path, func(key string, field reflect.StructField) {
+		if indirect(field.Type).Kind() == reflect.Map {
+			*prefixes = append(*prefixes, key+".")
+			return
+		}
+		known[key] = struct{}{}
+	})
+}
+
+// walkKeys calls fn with keys of leaf and map fields of given struct type t, named by json tags.
+func walkKeys(t reflect.Type, path string, fn func(key string, field reflect.StructField)) {
+	t = indirect(t)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		// fields of embedded structs are promoted like encoding/json.
+		if f.Anonymous && name == "" && indirect(f.Type).Kind() == reflect.Struct {
+			walkKeys(f.Type, path, fn)
+			continue
+		}
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if path != "" {
+			name = path + "." + name
+		}
+		if indirect(f.Type).Kind() == reflect.Struct {
+			walkKeys(f.Type, name, fn)
+			continue
+		}
+		fn(name, f)
+	}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package cfgloader
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -18,7 +19,8 @@ type config struct {
 }
 
 type databaseConfig struct {
-	DSN string `json:"dsn"`
+	DSN  string            `json:"dsn"`
+	Keys map[string]string `json:"keys"`
 }
 
 func TestLoadWithOptions(t *testing.T) {
@@ -74,3 +76,92 @@ func TestLoadWithConfigFile(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 3*time.Minute, conf.Server.WriteTimeout)
 }
+
+func TestUnknownKeys(t *testing.T) {
+	var conf config
+	k, err := LoadWithOptions(&conf, nil, WithConfigMap(map[string]interface{}{
+		"server.port":    8080,
+		"server.timeout": "5m",
+		"db.dsn":         "root:password@tcp(127.0.0.1:3306)",
+		"db.keys.key1":   "value1",
+		"database.dsn":   "root:password@tcp(127.0.0.1:3306)",
+	}))
+	assert.NoError(t, err)
+
+	unknown := UnknownKeys(k, &conf)
+
+	assert.Equal(t, []string{"database.dsn", "server.timeout"}, unknown)
+}
+
+func TestOrigins(t *testing.T) {
+	t.Setenv("ORIGIN_APP_SERVER_READ-TIMEOUT", "2m")
+	t.Setenv("ORIGIN_APP_DB_DSN_FILE", "/run/secrets/dsn")
+	t.Setenv("ORIGIN_APP_SERVER_HOSTS", "c.com")
+
+	origins, err := Origins(map[string]interface{}{
+		"server.port":          8080,
+		"server.read-timeout":  "1m",
+		"server.write-timeout": "1m",
+		"server.hosts":         []string{"a.com", "b.com"},
+		"server.sinks":         []interface{}{map[string]interface{}{"path": "stdout"}},
+	},
+		Source{Name: "env", Option: WithEnv("ORIGIN_APP_")},
+		Source{Name: "file", Option: WithConfigFile("./test-config.yml")},
+		Source{Name: "override", Option: WithConfigMap(map[string]interface{}{"db.keys.k1": "v1"})},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"server.port":          SourceDefault,
+		"server.read-timeout":  "env",
+		"server.write-timeout": "file",
+		// elements of lists replaced by later sources are removed.
+		"server.hosts":        "env",
+		"server.hosts.0":      "env",
+		"server.sinks":        SourceDefault,
+		"server.sinks.0":      SourceDefault,
+		"server.sinks.0.path": SourceDefault,
+		"db.dsn":              "env",
+		"db.keys.k1":          "override",
+	}, origins)
+}
+
+func TestDescribe(t *testing.T) {
+	var conf struct {
+		config
+		Password string `json:"password" secret:"true"`
+	}
+
+	docs, err := Describe(&conf, map[string]interface{}{
+		"server.port":  8080,
+		"db.keys.k1":   "v1",
+		"unknown.port": 8081,
+	}, "MY_APP_")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []KeyDoc{
+		{Key: "db.dsn", Type: "string", Env: "MY_APP_DB_DSN"},
+		{Key: "db.keys.*", Type: "map[string]string", Default: map[string]interface{}{"k1": "v1"}, Env: "MY_APP_DB_KEYS_*"},
+		{Key: "password", Type: "string", Env: "MY_APP_PASSWORD", Secret: true},
+		{Key: "server.port", Type: "int", Default: 8080, Env: "MY_APP_SERVER_PORT"},
+		{Key: "server.read-timeout", Type: "time.Duration", Env: "MY_APP_SERVER_READ-TIMEOUT"},
+		{Key: "server.write-timeout", Type: "time.Duration", Env: "MY_APP_SERVER_WRITE-TIMEOUT"},
+	}, docs)
+}
+
+func TestConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.yml", "a.json", "c.yaml", "ignored.txt"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+	}
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "d.yml"), 0755))
+
+	files, err := ConfigFiles(dir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.yml"), filepath.Join(dir, "c.yaml")}, files)
+
+	files, err = ConfigFiles(filepath.Join(dir, "not-exist"))
+	assert.NoError(t, err)
+	assert.Nil(t, files)
+}
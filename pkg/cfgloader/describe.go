@@ -0,0 +1,58 @@
+package cfgloader
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+// KeyDoc describes a config key.
+type KeyDoc struct {
+	// Key is a "." separated key. Keys of map fields end with ".*".
+	Key string
+	// Type is a go type of the key.
+	Type string
+	// Default is a default value of the key or nil if not exist.
+	// Defaults of map fields are keyed by the rest of keys.
+	Default interface{}
+	// Env is a name of the environment variable loaded by WithEnv with the prefix.
+	Env string
+	// Secret is true if the field has `secret:"true"` tag.
+	Secret bool
+}
+
+// Describe returns KeyDocs of all keys of given conf sorted by keys.
+func Describe(conf interface{}, defaultConf map[string]interface{}, envPrefix string) ([]KeyDoc, error) {
+	defaults := koanf.New(".")
+	if len(defaultConf) != 0 {
+		if err := defaults.Load(confmap.Provider(defaultConf, "."), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	var docs []KeyDoc
+	walkKeys(reflect.TypeOf(conf), "", func(key string, field reflect.StructField) {
+		doc := KeyDoc{
+			Key:    key,
+			Type:   field.Type.String(),
+			Secret: field.Tag.Get("secret") == "true",
+		}
+		if indirect(field.Type).Kind() == reflect.Map {
+			doc.Key += ".*"
+			if defaults.Exists(key) {
+				doc.Default = defaults.Cut(key).All()
+			}
+		} else if defaults.Exists(key) {
+			doc.Default = defaults.Get(key)
+		}
+		doc.Env = envPrefix + strings.ToUpper(strings.ReplaceAll(doc.Key, ".", "_"))
+		docs = append(docs, doc)
+	})
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Key < docs[j].Key
+	})
+	return docs, nil
+}
@@ -0,0 +1,65 @@
+package cfgloader
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+const (
+	fileRefPrefix = "file://"
+	envRefPrefix  = "env://"
+	fileSuffix    = ".file"
+)
+
+// IsSecretRef returns true if given value is a reference to a secret,
+// e.g. "file:///run/secrets/jwt-key" or "env://JWT_KEY".
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, fileRefPrefix) || strings.HasPrefix(value, envRefPrefix)
+}
+
+// ResolveSecret returns a secret of given ref. Trailing newlines of files are trimmed.
+func ResolveSecret(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, fileRefPrefix):
+		path := strings.TrimPrefix(ref, fileRefPrefix)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read secret file: %w", err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	case strings.HasPrefix(ref, envRefPrefix):
+		name := strings.TrimPrefix(ref, envRefPrefix)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret env %s is not set", name)
+		}
+		return value, nil
+	default:
+		return ref, nil
+	}
+}
+
+// resolveSecrets returns a copy of given k whose secret references are replaced with the secrets.
+func resolveSecrets(k *koanf.Koanf) (*koanf.Koanf, error) {
+	values := k.All()
+	for key, v := range values {
+		ref, ok := v.(string)
+		if !ok || !IsSecretRef(ref) {
+			continue
+		}
+		secret, err := ResolveSecret(ref)
+		if err != nil {
+			return nil, fmt.Errorf("resolve secret of %s: %w", key, err)
+		}
+		values[key] = secret
+	}
+	resolved := koanf.New(".")
+	if err := resolved.Load(confmap.Provider(values, "."), nil); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
@@ -0,0 +1,54 @@
+package cfgloader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	assert.NoError(t, os.WriteFile(path, []byte("file-secret\r\n"), 0600))
+	t.Setenv("TEST_SECRET", "env-secret")
+
+	cases := []struct {
+		ref      string
+		expected string
+		err      bool
+	}{
+		{ref: "file://" + path, expected: "file-secret"},
+		{ref: "env://TEST_SECRET", expected: "env-secret"},
+		{ref: "literal", expected: "literal"},
+		{ref: "file://" + path + ".missing", err: true},
+		{ref: "env://TEST_MISSING_SECRET", err: true},
+	}
+
+	for _, tc := range cases {
+		secret, err := ResolveSecret(tc.ref)
+		if tc.err {
+			assert.Error(t, err, tc.ref)
+			continue
+		}
+		assert.NoError(t, err, tc.ref)
+		assert.Equal(t, tc.expected, secret)
+	}
+}
+
+func TestLoadWithSecretRef(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dsn")
+	assert.NoError(t, os.WriteFile(path, []byte("file-dsn\n"), 0600))
+	t.Setenv("MY_APP_DB_DSN_FILE", path)
+	var conf config
+
+	k, err := LoadWithOptions(&conf, nil, WithEnv("MY_APP_"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "file-dsn", conf.DB.DSN)
+	assert.Equal(t, "file://"+path, k.String("db.dsn"))
+
+	assert.NoError(t, os.WriteFile(path, []byte("rotated-dsn\n"), 0600))
+	assert.NoError(t, Reload(k, &conf))
+	assert.Equal(t, "rotated-dsn", conf.DB.DSN)
+}
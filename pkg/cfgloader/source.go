@@ -0,0 +1,78 @@
+package cfgloader
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+// SourceDefault is an origin of keys in default configs.
+const SourceDefault = "default"
+
+// Source is an Option with a name to track origins of keys.
+type Source struct {
+	Name   string
+	Option Option
+}
+
+// Origins returns names of the sources which set each key last in given order.
+// Keys of given defaultConf have SourceDefault origin unless overridden.
+// Elements of lists have the origin of their lists with keys of indexes, e.g. "endpoints.0" and "sinks.0.path".
+func Origins(defaultConf map[string]interface{}, sources ...Source) (map[string]string, error) {
+	var (
+		origins = make(map[string]string)
+		merged  = koanf.New(".")
+	)
+	if len(defaultConf) != 0 {
+		if err := merged.Load(confmap.Provider(defaultConf, "."), nil); err != nil {
+			return nil, err
+		}
+		for _, key := range merged.Keys() {
+			setOrigin(origins, key, merged.Get(key), SourceDefault)
+		}
+	}
+	for _, s := range sources {
+		// loads each source alone to collect keys of it.
+		k := koanf.New(".")
+		if err := s.Option(k); err != nil {
+			return nil, err
+		}
+		// loads the source over previous ones to read merged values like Load, e.g. lists split from env.
+		if err := s.Option(merged); err != nil {
+			return nil, err
+		}
+		for _, key := range k.Keys() {
+			setOrigin(origins, key, merged.Get(key), s.Name)
+		}
+	}
+	return origins, nil
+}
+
+// setOrigin sets given name to the origin of given key and elements of given value if it's a list.
+// Elements set by previous sources are removed since lists are replaced as a whole.
+func setOrigin(origins map[string]string, key string, value interface{}, name string) {
+	prefix := key + "."
+	for k := range origins {
+		if strings.HasPrefix(k, prefix) {
+			delete(origins, k)
+		}
+	}
+	origins[key] = name
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			setOrigin(origins, prefix+strconv.Itoa(i), rv.Index(i).Interface(), name)
+		}
+	case reflect.Map:
+		// maps in lists are not flattened by koanf.
+		iter := rv.MapRange()
+		for iter.Next() {
+			setOrigin(origins, prefix+iter.Key().String(), iter.Value().Interface(), name)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"github.com/zacscoding/go-rest-template/pkg/utils/authutil"
+	"gorm.io/gorm"
+)
+
+const (
+	createdByColumn = "created_by"
+	updatedByColumn = "updated_by"
+)
+
+// RegisterAuditCallbacks registers callbacks to fill "created_by" and "updated_by" columns
+// with the id of authutil.CurrentUser in the statement's context.
+// Models without these columns are not affected.
+func RegisterAuditCallbacks(db *gorm.DB) error {
+	err := db.Callback().Create().Before("gorm:create").Register("audit:before_create", func(db *gorm.DB) {
+		setAuditColumn(db, createdByColumn)
+		setAuditColumn(db, updatedByColumn)
+	})
+	if err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").Register("audit:before_update", func(db *gorm.DB) {
+		setAuditColumn(db, updatedByColumn)
+	})
+}
+
+func setAuditColumn(db *gorm.DB, column string) {
+	if db.Error != nil || db.Statement.Schema == nil || db.Statement.Schema.LookUpField(column) == nil {
+		return
+	}
+	p := authutil.CurrentUser(db.Statement.Context)
+	if p == nil {
+		return
+	}
+	db.Statement.SetColumn(column, p.GetID(), true)
+}
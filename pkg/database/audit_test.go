@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zacscoding/go-rest-template/pkg/utils/authutil"
+	"gorm.io/gorm"
+)
+
+type TestAuditUser struct {
+	ID        uint `gorm:"primarykey"`
+	Name      string
+	CreatedBy uint
+	UpdatedBy uint
+}
+
+type testPrincipal uint
+
+func (p testPrincipal) GetID() uint          { return uint(p) }
+func (p testPrincipal) ToModel() interface{} { return p }
+
+func testAuditCallbacks(t *testing.T, db *gorm.DB) {
+	assert.NoError(t, RegisterAuditCallbacks(db))
+
+	t.Run("Anonymous", func(t *testing.T) {
+		u := TestAuditUser{Name: "user1"}
+
+		assert.NoError(t, db.WithContext(context.TODO()).Create(&u).Error)
+
+		var find TestAuditUser
+		assert.NoError(t, db.First(&find, u.ID).Error)
+		assert.EqualValues(t, 0, find.CreatedBy)
+		assert.EqualValues(t, 0, find.UpdatedBy)
+	})
+
+	t.Run("Current User", func(t *testing.T) {
+		creator := authutil.WithUserContext(context.TODO(), testPrincipal(1))
+		updater := authutil.WithUserContext(context.TODO(), testPrincipal(2))
+		u := TestAuditUser{Name: "user2"}
+
+		assert.NoError(t, db.WithContext(creator).Create(&u).Error)
+		assert.EqualValues(t, 1, u.CreatedBy)
+		assert.EqualValues(t, 1, u.UpdatedBy)
+		u.Name = "user2_1"
+		assert.NoError(t, db.WithContext(updater).Save(&u).Error)
+
+		var find TestAuditUser
+		assert.NoError(t, db.First(&find, u.ID).Error)
+		assert.EqualValues(t, 1, find.CreatedBy)
+		assert.EqualValues(t, 2, find.UpdatedBy)
+	})
+}
@@ -25,3 +25,27 @@ func TestFromContext(t *testing.T) {
 		assert.Equal(t, &newDB, db1)
 	})
 }
+
+func TestAfterCommit(t *testing.T) {
+	t.Run("no tx in context", func(t *testing.T) {
+		called := false
+
+		AfterCommit(context.Background(), func() { called = true })
+
+		assert.True(t, called)
+	})
+
+	t.Run("tx in context", func(t *testing.T) {
+		var (
+			hooks  = new(afterCommitHooks)
+			txDB   = gorm.DB{Statement: &gorm.Statement{Context: context.WithValue(context.Background(), afterCommitKey{}, hooks)}}
+			called = false
+		)
+
+		AfterCommit(WithContext(context.Background(), &txDB), func() { called = true })
+
+		assert.False(t, called)
+		hooks.run()
+		assert.True(t, called)
+	})
+}
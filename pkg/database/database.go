@@ -1,12 +1,19 @@
 package database
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
+	"sync"
 	"time"
 
+	"github.com/hashicorp/go-multierror"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"gorm.io/gorm"
 )
 
@@ -25,10 +32,16 @@ type Config struct {
 	LoggingPrefix  string `json:"logging-prefix" yaml:"logging-prefix"`
 	BatchSize      int    `json:"batch-size" yaml:"batch-size"`
 	Migrate        struct {
-		Enabled bool   `json:"enabled" yaml:"enabled"`
-		Dir     string `json:"dir" yaml:"dir"`
+		Enabled bool `json:"enabled" yaml:"enabled"`
+		// Dir is a directory of migration files which overrides FS if not empty.
+		Dir string `json:"dir" yaml:"dir"`
+		// FS contains migration files embedded in the binary.
+		FS fs.FS `json:"-" yaml:"-"`
+		// Backfills fill blind indexes at their versions before later migrations are applied.
+		Backfills []Backfill `json:"-" yaml:"-"`
 	} `json:"migrate" yaml:"migrate"`
-	Pool struct {
+	Encryption EncryptionConfig `json:"encryption" yaml:"encryption"`
+	Pool       struct {
 		MaxOpen     int           `json:"max-open" yaml:"max-open"`
 		MaxIdle     int           `json:"max-idle" yaml:"max-idle"`
 		MaxLifeTime time.Duration `json:"max-lifetime" yaml:"max-lifetime"`
@@ -43,14 +56,98 @@ type Config struct {
 	} `json:"replica" yaml:"replica"`
 }
 
-// Open returns a new gorm.DB for given conf Config.
-func Open(conf *Config) (*gorm.DB, error) {
+// Validate returns errors of invalid configs.
+func (c *Config) Validate() error {
+	var result *multierror.Error
+	if c.Driver != "mysql" {
+		result = multierror.Append(result, fmt.Errorf("%w: %q", ErrUnsupportedDriver, c.Driver))
+	}
+	if c.DataSourceName == "" {
+		result = multierror.Append(result, errors.New("require data-source-name"))
+	}
+	if c.LoggingLevel < int(zapcore.DebugLevel) || c.LoggingLevel > int(zapcore.FatalLevel) {
+		result = multierror.Append(result, fmt.Errorf("logging-level must be in [-1, 5]: %d", c.LoggingLevel))
+	}
+	if c.BatchSize <= 0 {
+		result = multierror.Append(result, fmt.Errorf("batch-size must be positive: %d", c.BatchSize))
+	}
+	if c.Pool.MaxOpen < 0 || c.Pool.MaxIdle < 0 || c.Pool.MaxLifeTime < 0 {
+		result = multierror.Append(result, errors.New("pool configs must not be negative"))
+	}
+	if c.Pool.MaxOpen > 0 && c.Pool.MaxIdle > c.Pool.MaxOpen {
+		result = multierror.Append(result, fmt.Errorf("pool.max-idle %d exceeds pool.max-open %d", c.Pool.MaxIdle, c.Pool.MaxOpen))
+	}
+	for i, dsn := range c.Replica.DataSourceNames {
+		if dsn == "" {
+			result = multierror.Append(result, fmt.Errorf("replica.data-source-names[%d] is empty", i))
+		}
+	}
+	if _, err := NewEncryptor(c); err != nil {
+		result = multierror.Append(result, fmt.Errorf("encryption: %w", err))
+	}
+	return result.ErrorOrNil()
+}
+
+// Open returns a new gorm.DB for given conf Config and the SchemaStatus read while opening it.
+// The SchemaStatus is nil if no migrations are configured.
+// Audit callbacks and encryption of given enc Encryptor are registered to the returned gorm.DB.
+// The database is migrated with backfills of conf.Migrate and fails if the schema is outdated
+// or blind indexes of the backfills are missing. Statements are logged by the level of conf.LoggingPrefix in given levels.
+// Logs without a logger in the context are written by given logger or the default logger if nil.
+func Open(conf *Config, enc *Encryptor, logger *zap.SugaredLogger, levels *logging.Levels) (*gorm.DB, *SchemaStatus, error) {
+	var (
+		db  *gorm.DB
+		err error
+		ctx = context.Background()
+	)
 	switch conf.Driver {
 	case "mysql":
-		return openMysqlDB(conf)
+		db, err = openMysqlDB(conf, logger, levels)
 	default:
-		return nil, ErrUnsupportedDriver
+		return nil, nil, ErrUnsupportedDriver
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := RegisterAuditCallbacks(db); err != nil {
+		return nil, nil, fmt.Errorf("register audit callbacks: %v", err)
+	}
+	if err := RegisterEncryption(db, enc); err != nil {
+		return nil, nil, fmt.Errorf("register encryption: %v", err)
+	}
+	if err := migrateMysqlDBWithBackfills(ctx, db, conf, logger); err != nil {
+		return nil, nil, err
+	}
+	status, err := checkSchemaStatus(ctx, conf, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkBackfills(ctx, db, conf, status); err != nil {
+		return nil, nil, err
+	}
+	return db, status, nil
+}
+
+// Reload applies rotated secrets of given conf Config to given db opened by Open.
+// New connections use the data source names of the conf and fields are encrypted with its keys.
+// Existing connections are kept until they are expired by conf.Pool.MaxLifeTime.
+// The blind index key can not be changed since blind indexes are looked up by the Encryptor given to stores.
+func Reload(db *gorm.DB, conf *Config) error {
+	enc, err := NewEncryptor(conf)
+	if err != nil {
+		return fmt.Errorf("encryption: %w", err)
 	}
+	if p, ok := db.Config.Plugins[encryptionPluginName].(*encryptionPlugin); ok {
+		if current := p.enc.Load(); current != nil && !bytes.Equal(current.indexKey, enc.indexKey) {
+			return errors.New("encryption: the blind index key can not be changed")
+		}
+	}
+	if p, ok := db.Config.Plugins[connectorsPluginName].(*connectorsPlugin); ok {
+		if err := p.setDSN(conf); err != nil {
+			return err
+		}
+	}
+	return RegisterEncryption(db, enc)
 }
 
 var DefaulTxOptions = &sql.TxOptions{
@@ -58,9 +155,47 @@ var DefaulTxOptions = &sql.TxOptions{
 	ReadOnly:  false,
 }
 
+type afterCommitKey struct{}
+
+// afterCommitHooks are functions called after a transaction is committed.
+type afterCommitHooks struct {
+	mu    sync.Mutex
+	hooks []func()
+}
+
+func (h *afterCommitHooks) add(f func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, f)
+}
+
+func (h *afterCommitHooks) run() {
+	h.mu.Lock()
+	hooks := h.hooks
+	h.hooks = nil
+	h.mu.Unlock()
+	for _, f := range hooks {
+		f()
+	}
+}
+
+// AfterCommit calls given f after the transaction of RunInTx stored in the context is committed.
+// The f is called immediately if the context has no transaction and is discarded if the transaction is rolled back.
+func AfterCommit(ctx context.Context, f func()) {
+	if db := FromContext(ctx, nil); db != nil && db.Statement != nil && db.Statement.Context != nil {
+		if hooks, ok := db.Statement.Context.Value(afterCommitKey{}).(*afterCommitHooks); ok {
+			hooks.add(f)
+			return
+		}
+	}
+	f()
+}
+
 // RunInTx begin transaction from given database and execute f.
+// Functions registered by AfterCommit with the txdb are called after the transaction is committed.
 func RunInTx(ctx context.Context, db *gorm.DB, opts *sql.TxOptions, f func(txdb *gorm.DB) error) error {
-	tx := db.WithContext(ctx).Begin(opts)
+	hooks := new(afterCommitHooks)
+	tx := db.WithContext(context.WithValue(ctx, afterCommitKey{}, hooks)).Begin(opts)
 	if tx.Error != nil {
 		return fmt.Errorf("start tx: %v", tx.Error)
 	}
@@ -75,5 +210,6 @@ func RunInTx(ctx context.Context, db *gorm.DB, opts *sql.TxOptions, f func(txdb
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("commit tx: %v", err)
 	}
+	hooks.run()
 	return nil
 }
@@ -2,6 +2,9 @@ package database
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -19,6 +22,33 @@ type TestCard struct {
 	TestUserID uint
 }
 
+func TestConfig_Validate(t *testing.T) {
+	var conf Config
+	conf.Driver = "mysql"
+	conf.DataSourceName = "root:password@tcp(127.0.0.1:3306)/mydb"
+	conf.LoggingLevel = 1
+	conf.BatchSize = 100
+	conf.Encryption.ActiveKeyID = "key1"
+	conf.Encryption.Keys = map[string]string{"key1": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))}
+	conf.Encryption.BlindIndexKey = base64.StdEncoding.EncodeToString([]byte("blind-index-key"))
+	assert.NoError(t, conf.Validate())
+
+	conf.Driver = "postgres"
+	conf.DataSourceName = ""
+	conf.BatchSize = 0
+	conf.Pool.MaxOpen = 5
+	conf.Pool.MaxIdle = 10
+	conf.Encryption.ActiveKeyID = "key2"
+
+	err := conf.Validate()
+
+	assert.ErrorIs(t, err, ErrUnsupportedDriver)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Contains(t, err.Error(), "require data-source-name")
+	assert.Contains(t, err.Error(), "batch-size must be positive")
+	assert.Contains(t, err.Error(), "pool.max-idle 10 exceeds pool.max-open 5")
+}
+
 func testRunInTx(t *testing.T, db *gorm.DB) {
 	name := "user1"
 	assert.NoError(t, db.Create(&TestUser{Name: name}).Error)
@@ -58,3 +88,39 @@ func testRunInTxRollback(t *testing.T, db *gorm.DB) {
 	assert.True(t, firstSuccess)
 	assert.Equal(t, ErrRecordNotFound, WrapError(db.Where("name = ?", name+"_1").First(&TestUser{}).Error))
 }
+
+func testRunInTxAfterCommit(t *testing.T, db *gorm.DB) {
+	t.Run("Commit", func(t *testing.T) {
+		called := false
+
+		err := RunInTx(context.TODO(), db, nil, func(txDb *gorm.DB) error {
+			ctx := WithContext(context.TODO(), txDb)
+			if err := FromContext(ctx, db).Create(&TestUser{Name: "after_commit"}).Error; err != nil {
+				return err
+			}
+			AfterCommit(ctx, func() {
+				called = true
+				assert.NoError(t, db.Where("name = ?", "after_commit").First(new(TestUser)).Error)
+			})
+			assert.False(t, called)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, called)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		called := false
+
+		err := RunInTx(context.TODO(), db, nil, func(txDb *gorm.DB) error {
+			AfterCommit(WithContext(context.TODO(), txDb), func() {
+				called = true
+			})
+			return errors.New("force rollback")
+		})
+
+		assert.Error(t, err)
+		assert.False(t, called)
+	})
+}
@@ -0,0 +1,406 @@
+package database
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// EncryptSerializer is a name of the serializer which encrypts string fields,
+	// e.g. `gorm:"column:email;serializer:encrypt"`.
+	EncryptSerializer = "encrypt"
+	// blindIndexTag is a tag key of the field storing a blind index of the other field,
+	// e.g. `gorm:"column:email_bidx;blindindex:Email"`.
+	blindIndexTag = "BLINDINDEX"
+
+	encryptedPrefix = "enc:"
+
+	encryptionPluginName = "encryption"
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown encryption key")
+	ErrInvalidEncrypted = errors.New("invalid encrypted value")
+
+	// registerSerializer registers EncryptSerializer once which resolves the Encryptor of a statement's db.
+	registerSerializer sync.Once
+)
+
+// EncryptionConfig represents configs of field-level encryption.
+type EncryptionConfig struct {
+	// ActiveKeyID is an id of the key to encrypt values.
+	ActiveKeyID string `json:"active-key-id" yaml:"active-key-id"`
+	// Keys are base64 encoded AES-128, 192 or 256 keys by id.
+	// Previous keys must be kept to decrypt values until they are rotated.
+	Keys map[string]string `json:"keys" yaml:"keys" secret:"true"`
+	// BlindIndexKey is a base64 encoded HMAC key of blind indexes. It can not be changed by Reload.
+	BlindIndexKey string `json:"blind-index-key" yaml:"blind-index-key" secret:"true"`
+}
+
+// Encryptor encrypts values with AES-GCM and computes blind indexes with HMAC-SHA256.
+type Encryptor struct {
+	activeKeyID string
+	aeads       map[string]cipher.AEAD
+	indexKey    []byte
+}
+
+// NewEncryptor returns a new Encryptor from encryption configs of given conf Config.
+func NewEncryptor(conf *Config) (*Encryptor, error) {
+	encconf := conf.Encryption
+	if encconf.ActiveKeyID == "" || len(encconf.Keys) == 0 {
+		return nil, errors.New("require active-key-id and keys")
+	}
+	enc := Encryptor{
+		activeKeyID: encconf.ActiveKeyID,
+		aeads:       make(map[string]cipher.AEAD, len(encconf.Keys)),
+	}
+	for id, encoded := range encconf.Keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid encryption key id: %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decode encryption key %s: %v", id, err)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("new cipher of encryption key %s: %v", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("new gcm of encryption key %s: %v", id, err)
+		}
+		enc.aeads[id] = aead
+	}
+	if _, ok := enc.aeads[enc.activeKeyID]; !ok {
+		return nil, fmt.Errorf("%w: active key %q", ErrUnknownKey, enc.activeKeyID)
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(encconf.BlindIndexKey)
+	if err != nil {
+		return nil, fmt.Errorf("decode blind index key: %v", err)
+	}
+	if len(indexKey) == 0 {
+		return nil, errors.New("require blind index key")
+	}
+	enc.indexKey = indexKey
+	return &enc, nil
+}
+
+// Encrypt encrypts given plaintext with the active key.
+// The returned value is formatted as "enc:{key id}:{base64 encoded nonce and ciphertext}".
+func (e *Encryptor) Encrypt(plaintext string) (string, error) {
+	aead := e.aeads[e.activeKeyID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefix + e.activeKeyID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts given value encrypted by Encrypt with the key of its key id.
+// A value without "enc:" prefix is returned as it is to read values written before encryption.
+func (e *Encryptor) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !ok {
+		return "", ErrInvalidEncrypted
+	}
+	aead, ok := e.aeads[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidEncrypted
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidEncrypted, err)
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a hex encoded HMAC-SHA256 of given value to find encrypted values.
+// The value is lower-cased so that lookups are case-insensitive.
+func (e *Encryptor) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, e.indexKey)
+	mac.Write([]byte(strings.ToLower(value)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RegisterEncryption registers EncryptSerializer encrypting fields with given enc Encryptor in given db
+// and callbacks to fill blind index fields before creating and updating.
+// The Encryptor is scoped to the db and replaced if it is registered again.
+func RegisterEncryption(db *gorm.DB, enc *Encryptor) error {
+	registerSerializer.Do(func() {
+		schema.RegisterSerializer(EncryptSerializer, encryptSerializer{})
+	})
+	if p, ok := db.Config.Plugins[encryptionPluginName].(*encryptionPlugin); ok {
+		p.enc.Store(enc)
+		return nil
+	}
+	p := &encryptionPlugin{}
+	p.enc.Store(enc)
+	return db.Use(p)
+}
+
+// ReEncrypt re-encrypts encrypted fields and blind indexes of all rows including deleted ones
+// of given model with the active key in batches of batchSize. Returns the number of re-encrypted rows.
+func ReEncrypt(ctx context.Context, db *gorm.DB, model interface{}, batchSize int) (int64, error) {
+	return reEncrypt(ctx, db, model, batchSize, false)
+}
+
+// BackfillBlindIndexes re-encrypts rows of given model having NULL blind indexes, e.g. rows written
+// before blind index columns are added, in batches of batchSize. Returns the number of re-encrypted rows.
+func BackfillBlindIndexes(ctx context.Context, db *gorm.DB, model interface{}, batchSize int) (int64, error) {
+	return reEncrypt(ctx, db, model, batchSize, true)
+}
+
+// CountMissingBlindIndexes returns the number of rows of given model including deleted ones having NULL blind indexes.
+func CountMissingBlindIndexes(ctx context.Context, db *gorm.DB, model interface{}) (int64, error) {
+	stmt := gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return 0, err
+	}
+	_, indexes := encryptedColumns(stmt.Schema)
+	if len(indexes) == 0 {
+		return 0, nil
+	}
+	var count int64
+	err := db.WithContext(ctx).Unscoped().Model(model).Where(missingBlindIndexes(indexes)).Count(&count).Error
+	return count, WrapError(err)
+}
+
+func reEncrypt(ctx context.Context, db *gorm.DB, model interface{}, batchSize int, missingOnly bool) (int64, error) {
+	stmt := gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return 0, err
+	}
+	columns, indexes := encryptedColumns(stmt.Schema)
+	if len(columns) == 0 || stmt.Schema.PrioritizedPrimaryField == nil || (missingOnly && len(indexes) == 0) {
+		return 0, nil
+	}
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	var (
+		pk    = stmt.Schema.PrioritizedPrimaryField
+		after interface{}
+		total int64
+	)
+	for {
+		rows := reflect.New(reflect.SliceOf(reflect.TypeOf(model)))
+		err := RunInTx(ctx, db, DefaulTxOptions, func(txdb *gorm.DB) error {
+			query := txdb.Unscoped().
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Order(clause.OrderByColumn{Column: clause.Column{Name: pk.DBName}}).
+				Limit(batchSize)
+			if after != nil {
+				query = query.Where(clause.Gt{Column: clause.Column{Name: pk.DBName}, Value: after})
+			}
+			if missingOnly {
+				query = query.Where(missingBlindIndexes(indexes))
+			}
+			if err := query.Find(rows.Interface()).Error; err != nil {
+				return WrapError(err)
+			}
+			for i := 0; i < rows.Elem().Len(); i++ {
+				row := rows.Elem().Index(i).Interface()
+				if err := txdb.Unscoped().Model(row).Select(columns).UpdateColumns(row).Error; err != nil {
+					return WrapError(err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+
+		n := rows.Elem().Len()
+		total += int64(n)
+		if n < batchSize {
+			return total, nil
+		}
+		after, _ = pk.ValueOf(ctx, reflect.Indirect(rows.Elem().Index(n-1)))
+	}
+}
+
+// encryptedColumns returns columns of encrypted fields and blind indexes, and columns of blind indexes only.
+func encryptedColumns(s *schema.Schema) (columns, indexes []string) {
+	for _, field := range s.Fields {
+		_, blindIndex := field.TagSettings[blindIndexTag]
+		if blindIndex {
+			indexes = append(indexes, field.DBName)
+		}
+		if blindIndex || strings.EqualFold(field.TagSettings["SERIALIZER"], EncryptSerializer) {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns, indexes
+}
+
+// missingBlindIndexes returns a condition matched with rows having any NULL column of given indexes.
+func missingBlindIndexes(indexes []string) clause.Expression {
+	exprs := make([]clause.Expression, 0, len(indexes))
+	for _, column := range indexes {
+		exprs = append(exprs, clause.Eq{Column: clause.Column{Name: column}, Value: nil})
+	}
+	return clause.Or(exprs...)
+}
+
+type encryptorKey struct{}
+
+// encryptionPlugin is a gorm.Plugin scoping an Encryptor to statements of a db.
+type encryptionPlugin struct {
+	enc atomic.Pointer[Encryptor]
+}
+
+func (p *encryptionPlugin) Name() string {
+	return encryptionPluginName
+}
+
+func (p *encryptionPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("*").Register("encryption:scope", p.scope),
+		cb.Query().Before("*").Register("encryption:scope", p.scope),
+		cb.Update().Before("*").Register("encryption:scope", p.scope),
+		cb.Delete().Before("*").Register("encryption:scope", p.scope),
+		cb.Row().Before("*").Register("encryption:scope", p.scope),
+		cb.Raw().Before("*").Register("encryption:scope", p.scope),
+		cb.Create().Before("gorm:create").Register("encryption:before_create", p.setBlindIndexes),
+		cb.Update().Before("gorm:update").Register("encryption:before_update", p.setBlindIndexes),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scope sets the Encryptor to the context of the statement used by EncryptSerializer.
+func (p *encryptionPlugin) scope(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	db.Statement.Context = context.WithValue(ctx, encryptorKey{}, p.enc.Load())
+}
+
+// setBlindIndexes sets blind index fields with values of the fields given by "blindindex" tags.
+func (p *encryptionPlugin) setBlindIndexes(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	var (
+		enc = p.enc.Load()
+		ctx = db.Statement.Context
+		rv  = db.Statement.ReflectValue
+	)
+	for _, field := range db.Statement.Schema.Fields {
+		name, ok := field.TagSettings[blindIndexTag]
+		if !ok {
+			continue
+		}
+		src := db.Statement.Schema.LookUpField(name)
+		if src == nil {
+			_ = db.AddError(fmt.Errorf("unknown blind index source field: %s", name))
+			return
+		}
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				elem := reflect.Indirect(rv.Index(i))
+				value := src.ReflectValueOf(ctx, elem).Interface()
+				if err := field.Set(ctx, elem, enc.blindIndexOf(value)); err != nil {
+					_ = db.AddError(err)
+					return
+				}
+			}
+		case reflect.Struct:
+			value := src.ReflectValueOf(ctx, rv).Interface()
+			db.Statement.SetColumn(field.DBName, enc.blindIndexOf(value), true)
+		}
+	}
+}
+
+func (e *Encryptor) blindIndexOf(value interface{}) string {
+	s, _ := value.(string)
+	if s == "" {
+		return ""
+	}
+	return e.BlindIndex(s)
+}
+
+// encryptSerializer is a schema.SerializerInterface which encrypts string fields
+// with the Encryptor registered in the db of a statement by RegisterEncryption.
+type encryptSerializer struct{}
+
+func encryptorOf(ctx context.Context, field *schema.Field) (*Encryptor, error) {
+	if ctx != nil {
+		if enc, ok := ctx.Value(encryptorKey{}).(*Encryptor); ok {
+			return enc, nil
+		}
+	}
+	return nil, fmt.Errorf("no encryptor is registered to encrypt %s", field.Name)
+}
+
+func (encryptSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("%w: unsupported type %T of %s", ErrInvalidEncrypted, dbValue, field.Name)
+	}
+	enc, err := encryptorOf(ctx, field)
+	if err != nil {
+		return err
+	}
+	plaintext, err := enc.Decrypt(value)
+	if err != nil {
+		return err
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+func (encryptSerializer) Value(ctx context.Context, field *schema.Field, _ reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %T of %s to encrypt", fieldValue, field.Name)
+	}
+	if value == "" {
+		return "", nil
+	}
+	enc, err := encryptorOf(ctx, field)
+	if err != nil {
+		return nil, err
+	}
+	return enc.Encrypt(value)
+}
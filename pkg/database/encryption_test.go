@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+type TestSecretUser struct {
+	ID         uint   `gorm:"primarykey"`
+	Email      string `gorm:"serializer:encrypt"`
+	EmailIndex string `gorm:"blindindex:Email"`
+}
+
+func newTestEncryptor(t *testing.T, activeKeyID string, keyIDs ...string) *Encryptor {
+	var conf Config
+	conf.Encryption.ActiveKeyID = activeKeyID
+	conf.Encryption.Keys = make(map[string]string)
+	for _, id := range keyIDs {
+		conf.Encryption.Keys[id] = base64.StdEncoding.EncodeToString([]byte(strings.Repeat(id, 32)[:32]))
+	}
+	conf.Encryption.BlindIndexKey = base64.StdEncoding.EncodeToString([]byte("blind-index-key"))
+	enc, err := NewEncryptor(&conf)
+	assert.NoError(t, err)
+	return enc
+}
+
+func TestNewEncryptor_Fail(t *testing.T) {
+	cases := []struct {
+		name   string
+		update func(conf *EncryptionConfig)
+	}{
+		{name: "Empty Keys", update: func(conf *EncryptionConfig) { conf.ActiveKeyID, conf.Keys = "", nil }},
+		{name: "Unknown Active Key", update: func(conf *EncryptionConfig) { conf.ActiveKeyID = "k2" }},
+		{name: "Invalid Key Size", update: func(conf *EncryptionConfig) { conf.Keys["k1"] = "c2FtcGxlCg==" }},
+		{name: "Invalid Key ID", update: func(conf *EncryptionConfig) { conf.Keys["k:2"] = conf.Keys["k1"] }},
+		{name: "Empty Blind Index Key", update: func(conf *EncryptionConfig) { conf.BlindIndexKey = "" }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var conf Config
+			conf.Encryption = EncryptionConfig{
+				ActiveKeyID:   "k1",
+				Keys:          map[string]string{"k1": "c2FtcGxlLWFwcC1lbmNyeXB0aW9uLWtleS0zMmJ5dGU="},
+				BlindIndexKey: "c2FtcGxlLWFwcC1ibGluZC1pbmRleC1rZXk=",
+			}
+			tc.update(&conf.Encryption)
+
+			enc, err := NewEncryptor(&conf)
+
+			assert.Nil(t, enc)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestEncryptor_EncryptDecrypt(t *testing.T) {
+	enc := newTestEncryptor(t, "k1", "k1")
+
+	encrypted, err := enc.Encrypt("user1@email.com")
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "enc:k1:"))
+	assert.NotContains(t, encrypted, "user1@email.com")
+	decrypted, err := enc.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1@email.com", decrypted)
+
+	t.Run("Plaintext", func(t *testing.T) {
+		decrypted, err := enc.Decrypt("user1@email.com")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user1@email.com", decrypted)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		_, err := enc.Decrypt(encrypted[:len(encrypted)-4] + "AAA=")
+
+		assert.ErrorIs(t, err, ErrInvalidEncrypted)
+	})
+}
+
+func TestEncryptor_Rotate(t *testing.T) {
+	oldEnc := newTestEncryptor(t, "k1", "k1")
+	encrypted, err := oldEnc.Encrypt("user1@email.com")
+	assert.NoError(t, err)
+
+	newEnc := newTestEncryptor(t, "k2", "k1", "k2")
+	decrypted, err := newEnc.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1@email.com", decrypted)
+	reEncrypted, err := newEnc.Encrypt(decrypted)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(reEncrypted, "enc:k2:"))
+
+	removedEnc := newTestEncryptor(t, "k2", "k2")
+	_, err = removedEnc.Decrypt(encrypted)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestEncryptor_BlindIndex(t *testing.T) {
+	enc := newTestEncryptor(t, "k1", "k1")
+
+	assert.Equal(t, enc.BlindIndex("user1@email.com"), enc.BlindIndex("USER1@email.com"))
+	assert.NotEqual(t, enc.BlindIndex("user1@email.com"), enc.BlindIndex("user2@email.com"))
+	assert.Len(t, enc.BlindIndex("user1@email.com"), 64)
+}
+
+func TestRegisterEncryption(t *testing.T) {
+	newDB := func() *gorm.DB {
+		db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+		assert.NoError(t, err)
+		return db
+	}
+	encrypted := func(db *gorm.DB) (string, error) {
+		stmt := db.Create(&TestSecretUser{Email: "user1@email.com"}).Statement
+		for _, v := range stmt.Vars {
+			if valuer, ok := v.(driver.Valuer); ok {
+				value, err := valuer.Value()
+				s, _ := value.(string)
+				return s, err
+			}
+		}
+		return "", errors.New("no encrypted value")
+	}
+	db1, db2 := newDB(), newDB()
+	assert.NoError(t, RegisterEncryption(db1, newTestEncryptor(t, "k1", "k1")))
+	assert.NoError(t, RegisterEncryption(db2, newTestEncryptor(t, "k2", "k2")))
+
+	// encryptors are scoped to each db
+	value, err := encrypted(db1)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, "enc:k1:"))
+	value, err = encrypted(db2)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, "enc:k2:"))
+
+	// replace the encryptor of a db
+	assert.NoError(t, RegisterEncryption(db1, newTestEncryptor(t, "k3", "k3")))
+	value, err = encrypted(db1)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, "enc:k3:"))
+
+	// fail without an encryptor
+	_, err = encrypted(newDB())
+	assert.ErrorContains(t, err, "no encryptor")
+
+	t.Run("Blind Indexes", func(t *testing.T) {
+		enc := newTestEncryptor(t, "k1", "k1")
+		db := newDB()
+		assert.NoError(t, RegisterEncryption(db, enc))
+
+		u := TestSecretUser{Email: "user1@email.com"}
+		assert.NoError(t, db.Create(&u).Error)
+		assert.Equal(t, enc.BlindIndex("user1@email.com"), u.EmailIndex)
+		users := []TestSecretUser{{Email: "user2@email.com"}}
+		assert.NoError(t, db.Create(&users).Error)
+		assert.Equal(t, enc.BlindIndex("user2@email.com"), users[0].EmailIndex)
+	})
+}
+
+func TestReload_Encryption(t *testing.T) {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{DryRun: true})
+	assert.NoError(t, err)
+	enc := newTestEncryptor(t, "k1", "k1")
+	assert.NoError(t, RegisterEncryption(db, enc))
+	blindIndex := func() string {
+		u := TestSecretUser{Email: "user1@email.com"}
+		assert.NoError(t, db.Create(&u).Error)
+		return u.EmailIndex
+	}
+	var conf Config
+	conf.Encryption.ActiveKeyID = "k2"
+	conf.Encryption.Keys = map[string]string{
+		"k1": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k1", 16))),
+		"k2": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k2", 16))),
+	}
+	conf.Encryption.BlindIndexKey = base64.StdEncoding.EncodeToString([]byte("blind-index-key"))
+
+	// rows written after keys are rotated are found by blind indexes of the Encryptor given to stores.
+	assert.NoError(t, Reload(db, &conf))
+	assert.Equal(t, enc.BlindIndex("user1@email.com"), blindIndex())
+
+	conf.Encryption.BlindIndexKey = base64.StdEncoding.EncodeToString([]byte("rotated-index-key"))
+	assert.ErrorContains(t, Reload(db, &conf), "the blind index key can not be changed")
+	assert.Equal(t, enc.BlindIndex("user1@email.com"), blindIndex())
+}
+
+func testReEncrypt(t *testing.T, db *gorm.DB) {
+	oldEnc := newTestEncryptor(t, "k1", "k1")
+	assert.NoError(t, RegisterEncryption(db, oldEnc))
+	for _, email := range []string{"user1@email.com", "user2@email.com", "user3@email.com"} {
+		assert.NoError(t, db.Create(&TestSecretUser{Email: email}).Error)
+	}
+
+	newEnc := newTestEncryptor(t, "k2", "k1", "k2")
+	assert.NoError(t, RegisterEncryption(db, newEnc))
+	rows, err := ReEncrypt(context.TODO(), db, &TestSecretUser{}, 2)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, rows)
+	var raw []string
+	assert.NoError(t, db.Table("test_secret_users").Pluck("email", &raw).Error)
+	for _, v := range raw {
+		assert.True(t, strings.HasPrefix(v, "enc:k2:"))
+	}
+	var find TestSecretUser
+	assert.NoError(t, db.Where("email_index = ?", newEnc.BlindIndex("user2@email.com")).First(&find).Error)
+	assert.Equal(t, "user2@email.com", find.Email)
+}
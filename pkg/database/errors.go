@@ -14,6 +14,8 @@ var (
 	ErrKeyConflict = errors.New("conflict key")
 	// ErrFKConstraint is an error if foreign key constraint failed.
 	ErrFKConstraint = errors.New("a foreign key constraint fails")
+	// ErrStaleObject is an error if the record was modified by another transaction.
+	ErrStaleObject = errors.New("stale object")
 )
 
 // WrapError wraps given error to database error.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/zacscoding/go-rest-template/pkg/logging"
@@ -17,35 +18,40 @@ import (
 type Logger struct {
 	conf      glogger.Config
 	msgPrefix string
+	name      string
+	logger    *zap.SugaredLogger
 }
 
-// NewLogger returns a new logger for gorm. *zap.SugaredLogger will use from context.Context.
+// NewLogger returns a new logger for gorm. *zap.SugaredLogger will use from context.Context,
+// otherwise given logger or the default logger if nil.
+// Logs are written by a logger named with the trimmed prefix, e.g. "[DB]", whose initial level is set to given levels
+// of the logger, so the level can be changed at runtime. The level is not set if levels is nil.
 func NewLogger(slowThreshold time.Duration,
 	ignoreRecordNotFoundError bool,
 	level zapcore.Level,
 	prefix string,
+	logger *zap.SugaredLogger,
+	levels *logging.Levels,
 ) *Logger {
 	cfg := glogger.Config{
 		SlowThreshold:             slowThreshold,
 		Colorful:                  false,
 		IgnoreRecordNotFoundError: ignoreRecordNotFoundError,
-	}
-	switch level {
-	case zapcore.DebugLevel, zapcore.InfoLevel:
-		cfg.LogLevel = glogger.Info
-	case zapcore.WarnLevel:
-		cfg.LogLevel = glogger.Warn
-	case zapcore.ErrorLevel:
-		cfg.LogLevel = glogger.Error
-	default:
-		cfg.LogLevel = glogger.Silent
+		// logs are filtered by the level of the named logger.
+		LogLevel: glogger.Info,
 	}
 	if prefix == "" {
 		prefix = "[DB] "
 	}
+	name := strings.TrimSpace(prefix)
+	if levels != nil {
+		levels.SetLevel(name, level, 0)
+	}
 	return &Logger{
 		conf:      cfg,
 		msgPrefix: prefix,
+		name:      name,
+		logger:    logger,
 	}
 }
 
@@ -105,7 +111,7 @@ func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 		} else {
 			logger.Warnf(traceWarnStr, utils.FileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, rows, sql)
 		}
-	case l.conf.LogLevel == glogger.Info:
+	case l.conf.LogLevel == glogger.Info && logger.Desugar().Check(zapcore.InfoLevel, "") != nil:
 		sql, rows := fc()
 		if rows == -1 {
 			logger.Infof(traceStr, utils.FileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, "-", sql)
@@ -116,5 +122,5 @@ func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string,
 }
 
 func (l *Logger) fromContext(ctx context.Context) *zap.SugaredLogger {
-	return logging.FromContext(ctx).WithOptions(zap.AddCallerSkip(3))
+	return logging.FromContextOr(ctx, l.logger).Named(l.name).WithOptions(zap.AddCallerSkip(3))
 }
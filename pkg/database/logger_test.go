@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogger_ContextFields(t *testing.T) {
+	logger, logs := logging.NewObservedLogger(zapcore.InfoLevel)
+	ctx := logging.WithFields(context.Background(), logger, "requestId", "request1")
+	logging.AddFields(ctx, "userId", uint(1))
+	l := NewLogger(time.Second, true, zapcore.InfoLevel, "", nil, nil)
+
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, errors.New("force err"))
+
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "[DB]", entries[0].LoggerName)
+	assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+	assert.Equal(t, map[string]interface{}{"requestId": "request1", "userId": uint64(1)}, entries[0].ContextMap())
+}
+
+func TestLogger_Levels(t *testing.T) {
+	levels := logging.NewLevels(zapcore.InfoLevel)
+	logger, logs := logging.NewObservedLoggerWithLevels(levels)
+	l := NewLogger(time.Second, true, zapcore.WarnLevel, "", logger, levels)
+	ctx := context.Background()
+
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	assert.Equal(t, 0, logs.Len())
+	assert.Equal(t, zapcore.WarnLevel, levels.Level("[DB]"))
+	_, ok := logging.DefaultLevels().Named()["[DB]"]
+	assert.False(t, ok)
+
+	// the level can be changed at runtime.
+	levels.SetLevel("[DB]", zapcore.InfoLevel, 0)
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	assert.Equal(t, 1, logs.Len())
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSchemaOutdated is an error if the database schema is older than the migrations known to the binary.
+	ErrSchemaOutdated = errors.New("database schema is outdated")
+	// ErrMissingBlindIndex is an error if rows have NULL blind indexes after their backfills.
+	ErrMissingBlindIndex = errors.New("missing blind indexes")
+)
+
+// SchemaStatus represents the migration status of the database schema.
+type SchemaStatus struct {
+	// Version is the current version of the database schema. Zero means no migrations are applied.
+	Version uint `json:"version"`
+	// Dirty is true if the last migration was failed and must be fixed manually.
+	Dirty bool `json:"dirty"`
+	// Expected is the latest version of the migrations known to the binary.
+	Expected uint `json:"expected"`
+}
+
+// NewSchemaStatus returns the current SchemaStatus of the database for given conf Config.
+func NewSchemaStatus(conf *Config) (*SchemaStatus, error) {
+	src, err := openMigrationSource(conf)
+	if err != nil {
+		return nil, err
+	}
+	switch conf.Driver {
+	case "mysql":
+		return readMysqlSchemaStatus(conf.DataSourceName, src)
+	default:
+		_ = src.Close()
+		return nil, ErrUnsupportedDriver
+	}
+}
+
+// checkSchemaStatus logs the schema status with given logger and returns ErrSchemaOutdated
+// if the database schema is older than the migrations and auto migration is disabled.
+// It's skipped and returns nil status if no migrations are configured.
+func checkSchemaStatus(ctx context.Context, conf *Config, logger *zap.SugaredLogger) (*SchemaStatus, error) {
+	if conf.Migrate.Dir == "" && conf.Migrate.FS == nil {
+		return nil, nil
+	}
+	status, err := NewSchemaStatus(conf)
+	if err != nil {
+		return nil, err
+	}
+	logger = logging.FromContextOr(ctx, logger).With("version", status.Version, "dirty", status.Dirty, "expected", status.Expected)
+	if status.Dirty {
+		logger.Warn("database schema is dirty")
+	} else {
+		logger.Info("database schema status")
+	}
+	if !conf.Migrate.Enabled && status.Version < status.Expected {
+		return nil, fmt.Errorf("%w: version %d, expected %d", ErrSchemaOutdated, status.Version, status.Expected)
+	}
+	return status, nil
+}
+
+// Backfill fills blind indexes of Model after the migration of Version adds them,
+// so that migrations after Version can require the blind indexes, e.g. NOT NULL.
+type Backfill struct {
+	Version uint
+	Model   interface{}
+}
+
+// runBackfills migrates up to versions of backfills if migrations are enabled
+// and fills blind indexes of the backfills whose version is the current version.
+func runBackfills(ctx context.Context, db *gorm.DB, m *migrate.Migrate, conf *Config, logger *zap.SugaredLogger) error {
+	backfills := make([]Backfill, len(conf.Migrate.Backfills))
+	copy(backfills, conf.Migrate.Backfills)
+	sort.Slice(backfills, func(i, j int) bool { return backfills[i].Version < backfills[j].Version })
+
+	for _, b := range backfills {
+		version, _, err := m.Version()
+		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+		if version < b.Version && conf.Migrate.Enabled {
+			if err := m.Migrate(b.Version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+				return fmt.Errorf("failed run migrate to version %d: %w", b.Version, err)
+			}
+			version = b.Version
+		}
+		if version != b.Version {
+			continue
+		}
+		rows, err := BackfillBlindIndexes(ctx, db, b.Model, conf.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to backfill blind indexes at version %d: %w", b.Version, err)
+		}
+		logging.FromContextOr(ctx, logger).Infow("filled blind indexes", "version", b.Version, "rows", rows)
+	}
+	return nil
+}
+
+// checkBackfills returns ErrMissingBlindIndex if any row of backfills already migrated has NULL blind indexes.
+func checkBackfills(ctx context.Context, db *gorm.DB, conf *Config, status *SchemaStatus) error {
+	if status == nil {
+		return nil
+	}
+	for _, b := range conf.Migrate.Backfills {
+		if status.Version < b.Version {
+			continue
+		}
+		count, err := CountMissingBlindIndexes(ctx, db, b.Model)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %d rows of %T", ErrMissingBlindIndex, count, b.Model)
+		}
+	}
+	return nil
+}
+
+// openMigrationSource opens the migration directory if configured, otherwise the embedded migrations.
+func openMigrationSource(conf *Config) (source.Driver, error) {
+	if conf.Migrate.Dir != "" {
+		return openMigrationDir(conf.Migrate.Dir)
+	}
+	if conf.Migrate.FS == nil {
+		return nil, ErrEmptyDir
+	}
+	src, err := iofs.New(conf.Migrate.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded migrations: %w", err)
+	}
+	return src, nil
+}
+
+func openMigrationDir(dir string) (source.Driver, error) {
+	src, err := (&file.File{}).Open("file://" + dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations %s: %w", dir, err)
+	}
+	return src, nil
+}
+
+// latestMigrationVersion returns the last version of the given migration source.
+func latestMigrationVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestLatestMigrationVersion(t *testing.T) {
+	cases := []struct {
+		name     string
+		fs       fstest.MapFS
+		expected uint
+	}{
+		{
+			name: "Migrations",
+			fs: fstest.MapFS{
+				"000001_initial.up.sql":   {Data: []byte("CREATE TABLE t1 (id INT);")},
+				"000001_initial.down.sql": {Data: []byte("DROP TABLE t1;")},
+				"000003_add.up.sql":       {Data: []byte("CREATE TABLE t2 (id INT);")},
+			},
+			expected: 3,
+		},
+		{
+			name:     "Empty",
+			fs:       fstest.MapFS{"README.md": {Data: []byte("empty")}},
+			expected: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			src, err := iofs.New(tc.fs, ".")
+			assert.NoError(t, err)
+			defer src.Close()
+
+			version, err := latestMigrationVersion(src)
+
+			assert.NoError(t, err)
+			assert.EqualValues(t, tc.expected, version)
+		})
+	}
+}
+
+func testNewSchemaStatus(t *testing.T, dsn string) {
+	var conf Config
+	conf.Driver = "mysql"
+	conf.DataSourceName = dsn
+	conf.Migrate.Dir = "./migrations/mysql"
+
+	status, err := NewSchemaStatus(&conf)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, status.Version)
+	assert.EqualValues(t, 2, status.Expected)
+	_, err = checkSchemaStatus(context.TODO(), &conf, nil)
+	assert.ErrorIs(t, err, ErrSchemaOutdated)
+
+	assert.NoError(t, MigrateMysqlDB(dsn, conf.Migrate.Dir, true))
+	status, err = NewSchemaStatus(&conf)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, status.Version)
+	assert.False(t, status.Dirty)
+	_, err = checkSchemaStatus(context.TODO(), &conf, nil)
+	assert.NoError(t, err)
+}
+
+type TestBackfillUser struct {
+	ID         uint   `gorm:"primarykey"`
+	Email      string `gorm:"serializer:encrypt"`
+	EmailIndex string `gorm:"blindindex:Email"`
+}
+
+func testMigrateWithBackfills(t *testing.T, dsn string, db *gorm.DB) {
+	var conf Config
+	conf.Driver = "mysql"
+	conf.DataSourceName = dsn
+	conf.BatchSize = 1
+	conf.Migrate.Enabled = true
+	conf.Migrate.FS = fstest.MapFS{
+		"000001_initial.up.sql":       {Data: []byte("CREATE TABLE test_backfill_users (id BIGINT AUTO_INCREMENT PRIMARY KEY, email VARCHAR(512) NOT NULL);")},
+		"000002_add_index.up.sql":     {Data: []byte("ALTER TABLE test_backfill_users ADD COLUMN email_index VARCHAR(64) NULL;")},
+		"000003_require_index.up.sql": {Data: []byte("ALTER TABLE test_backfill_users MODIFY COLUMN email_index VARCHAR(64) NOT NULL;")},
+	}
+	conf.Migrate.Backfills = []Backfill{{Version: 2, Model: &TestBackfillUser{}}}
+	enc := newTestEncryptor(t, "k1", "k1")
+	assert.NoError(t, RegisterEncryption(db, enc))
+	// rows written before the blind index is added.
+	src, err := openMigrationSource(&conf)
+	assert.NoError(t, err)
+	m, err := newMysqlMigrate(dsn, src)
+	assert.NoError(t, err)
+	assert.NoError(t, m.Migrate(1))
+	assert.NoError(t, closeMigrate(m))
+	for _, email := range []string{"user1@email.com", "user2@email.com"} {
+		assert.NoError(t, db.Exec("INSERT INTO test_backfill_users (email) VALUES (?)", email).Error)
+	}
+
+	assert.NoError(t, migrateMysqlDBWithBackfills(context.TODO(), db, &conf, nil))
+
+	status, err := checkSchemaStatus(context.TODO(), &conf, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, status.Version)
+	assert.NoError(t, checkBackfills(context.TODO(), db, &conf, status))
+	var find TestBackfillUser
+	assert.NoError(t, db.Where("email_index = ?", enc.BlindIndex("user2@email.com")).First(&find).Error)
+	assert.Equal(t, "user2@email.com", find.Email)
+}
@@ -1,38 +1,47 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"log"
+	"sync/atomic"
 	"testing"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/golang-migrate/migrate"
-	"github.com/golang-migrate/migrate/database/mysql"
-	_ "github.com/golang-migrate/migrate/source/file"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/mysql"
+	"github.com/golang-migrate/migrate/v4/source"
 	"github.com/ory/dockertest/v3"
 	"github.com/zacscoding/go-rest-template/pkg/logging"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	gmysql "gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/plugin/dbresolver"
 )
 
-func openMysqlDB(conf *Config) (*gorm.DB, error) {
+func openMysqlDB(conf *Config, logger *zap.SugaredLogger, levels *logging.Levels) (*gorm.DB, error) {
 	var (
-		db     *gorm.DB
-		err    error
-		logger = NewLogger(time.Second, true, zapcore.Level(conf.LoggingLevel), conf.LoggingPrefix)
+		db       *gorm.DB
+		err      error
+		dbLogger = NewLogger(time.Second, true, zapcore.Level(conf.LoggingLevel), conf.LoggingPrefix, logger, levels)
 	)
 
+	primary, err := newDSNConnector(conf.DataSourceName)
+	if err != nil {
+		return nil, err
+	}
 	for i := 0; i < 20; i++ {
-		db, err = gorm.Open(gmysql.Open(conf.DataSourceName), &gorm.Config{Logger: logger})
+		// gorm closes the connection pool if failed to open.
+		db, err = gorm.Open(gmysql.New(gmysql.Config{Conn: sql.OpenDB(primary)}), &gorm.Config{Logger: dbLogger})
 		if err == nil {
 			break
 		}
-		logging.DefaultLogger().Warnf("failed to open database: %v", err)
+		logging.FromContextOr(context.Background(), logger).Warnf("failed to open database: %v", err)
 		time.Sleep(500 * time.Millisecond)
 	}
 	if err != nil {
@@ -47,9 +56,15 @@ func openMysqlDB(conf *Config) (*gorm.DB, error) {
 	rawDB.SetMaxIdleConns(conf.Pool.MaxIdle)
 	rawDB.SetConnMaxLifetime(conf.Pool.MaxLifeTime)
 
+	connectors := connectorsPlugin{primary: primary}
 	var replicas []gorm.Dialector
 	for _, dsn := range conf.Replica.DataSourceNames {
-		replicas = append(replicas, gmysql.Open(dsn))
+		replica, err := newDSNConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		connectors.replicas = append(connectors.replicas, replica)
+		replicas = append(replicas, gmysql.New(gmysql.Config{Conn: sql.OpenDB(replica)}))
 	}
 	if len(replicas) != 0 {
 		if err := db.Use(
@@ -61,37 +76,128 @@ func openMysqlDB(conf *Config) (*gorm.DB, error) {
 			return nil, fmt.Errorf("register replica resolvers: %v", err)
 		}
 	}
+	if err := db.Use(&connectors); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
 
-	if conf.Migrate.Enabled {
-		err := MigrateMysqlDB(conf.DataSourceName, conf.Migrate.Dir, true)
-		if err != nil {
-			return nil, err
+// dsnConnector is a driver.Connector connecting with the latest data source name,
+// so rotated passwords are applied to new connections.
+type dsnConnector struct {
+	connector atomic.Value // driver.Connector
+}
+
+func newDSNConnector(dsn string) (*dsnConnector, error) {
+	var c dsnConnector
+	if err := c.setDSN(dsn); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (c *dsnConnector) setDSN(dsn string) error {
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return err
+	}
+	connector, err := mysqldriver.NewConnector(cfg)
+	if err != nil {
+		return err
+	}
+	c.connector.Store(connector)
+	return nil
+}
+
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.connector.Load().(driver.Connector).Connect(ctx)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return mysqldriver.MySQLDriver{}
+}
+
+const connectorsPluginName = "connectors"
+
+// connectorsPlugin is a gorm.Plugin keeping connectors of the primary and replicas of a db to update them on reload.
+type connectorsPlugin struct {
+	primary  *dsnConnector
+	replicas []*dsnConnector
+}
+
+func (p *connectorsPlugin) Name() string {
+	return connectorsPluginName
+}
+
+func (p *connectorsPlugin) Initialize(*gorm.DB) error {
+	return nil
+}
+
+// setDSN updates data source names of the primary and replicas.
+func (p *connectorsPlugin) setDSN(conf *Config) error {
+	if len(conf.Replica.DataSourceNames) != len(p.replicas) {
+		return fmt.Errorf("the number of replicas can not be changed from %d to %d", len(p.replicas), len(conf.Replica.DataSourceNames))
+	}
+	if err := p.primary.setDSN(conf.DataSourceName); err != nil {
+		return err
+	}
+	for i, dsn := range conf.Replica.DataSourceNames {
+		if err := p.replicas[i].setDSN(dsn); err != nil {
+			return fmt.Errorf("replica %d: %w", i, err)
 		}
 	}
-	return db, nil
+	return nil
 }
 
-// MigrateMysqlDB migrates database from the given dsn data source name and migration directories.
-func MigrateMysqlDB(dsn, dir string, isUp bool) error {
-	db, err := sql.Open("mysql", dsn)
+// migrateMysqlDBWithBackfills migrates the database up if conf.Migrate.Enabled and fills blind indexes
+// of conf.Migrate.Backfills right after their versions are migrated. Backfills are also run without migrations
+// if the schema is at their versions, e.g. migrated by other tools.
+func migrateMysqlDBWithBackfills(ctx context.Context, db *gorm.DB, conf *Config, logger *zap.SugaredLogger) error {
+	if conf.Migrate.Dir == "" && conf.Migrate.FS == nil {
+		return nil
+	}
+	if !conf.Migrate.Enabled && len(conf.Migrate.Backfills) == 0 {
+		return nil
+	}
+	src, err := openMigrationSource(conf)
 	if err != nil {
-		return fmt.Errorf("failed create connect database: %w", err)
+		return err
 	}
-	driver, err := mysql.WithInstance(db, &mysql.Config{})
+	m, err := newMysqlMigrate(conf.DataSourceName, src)
 	if err != nil {
-		return fmt.Errorf("failed to mysql instance: %w", err)
+		return err
 	}
+	if err := runBackfills(ctx, db, m, conf, logger); err != nil {
+		_, _ = m.Close()
+		return err
+	}
+	if conf.Migrate.Enabled {
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			_, _ = m.Close()
+			return fmt.Errorf("failed run migrate: %w", err)
+		}
+	}
+	return closeMigrate(m)
+}
+
+// MigrateMysqlDB migrates database from the given dsn data source name and migration directories.
+func MigrateMysqlDB(dsn, dir string, isUp bool) error {
 	if dir == "" {
 		return ErrEmptyDir
 	}
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", dir),
-		"mysql",
-		driver,
-	)
+	src, err := openMigrationDir(dir)
 	if err != nil {
-		return fmt.Errorf("failed to new database instance: %w", err)
+		return err
 	}
+	return migrateMysqlDB(dsn, src, isUp)
+}
+
+func migrateMysqlDB(dsn string, src source.Driver, isUp bool) error {
+	m, err := newMysqlMigrate(dsn, src)
+	if err != nil {
+		return err
+	}
+
 	var migrateErr error
 	if isUp {
 		migrateErr = m.Up()
@@ -99,9 +205,59 @@ func MigrateMysqlDB(dsn, dir string, isUp bool) error {
 		migrateErr = m.Down()
 	}
 	if migrateErr != nil && !errors.Is(migrateErr, migrate.ErrNoChange) {
+		_, _ = m.Close()
 		return fmt.Errorf("failed run migrate: %w", migrateErr)
 	}
+	return closeMigrate(m)
+}
+
+// readMysqlSchemaStatus returns the schema status of the database from the given dsn and migration source.
+func readMysqlSchemaStatus(dsn string, src source.Driver) (*SchemaStatus, error) {
+	expected, err := latestMigrationVersion(src)
+	if err != nil {
+		_ = src.Close()
+		return nil, err
+	}
+	m, err := newMysqlMigrate(dsn, src)
+	if err != nil {
+		return nil, err
+	}
+
+	status := SchemaStatus{Expected: expected}
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		_, _ = m.Close()
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	status.Version, status.Dirty = version, dirty
+	if err := closeMigrate(m); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func newMysqlMigrate(dsn string, src source.Driver) (*migrate.Migrate, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("failed create connect database: %w", err)
+	}
+	driver, err := mysql.WithInstance(db, &mysql.Config{})
+	if err != nil {
+		_ = src.Close()
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to mysql instance: %w", err)
+	}
+	m, err := migrate.NewWithInstance("migrations", src, "mysql", driver)
+	if err != nil {
+		_ = src.Close()
+		_ = driver.Close()
+		return nil, fmt.Errorf("failed to new database instance: %w", err)
+	}
+	return m, nil
+}
 
+func closeMigrate(m *migrate.Migrate) error {
 	sourceErr, dbErr := m.Close()
 	if sourceErr != nil {
 		return fmt.Errorf("failed close source: %w", sourceErr)
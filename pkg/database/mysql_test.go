@@ -1,10 +1,14 @@
 package database
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
 )
@@ -12,6 +16,7 @@ import (
 var migrationTables = []string{
 	"mysql_migration_users",
 	"mysql_migration_users2",
+	"test_backfill_users",
 	"schema_migrations",
 }
 
@@ -31,11 +36,11 @@ func (s *MysqlSuite) SetupSuite() {
 }
 
 func (s *MysqlSuite) SetupTest() {
-	s.NoError(s.db.Migrator().AutoMigrate(new(TestUser), new(TestCard)))
+	s.NoError(s.db.Migrator().AutoMigrate(new(TestUser), new(TestCard), new(TestAuditUser), new(TestSecretUser)))
 }
 
 func (s *MysqlSuite) TearDownTest() {
-	s.NoError(s.db.Migrator().DropTable(new(TestUser), new(TestCard)))
+	s.NoError(s.db.Migrator().DropTable(new(TestUser), new(TestCard), new(TestAuditUser), new(TestSecretUser)))
 	for _, table := range migrationTables {
 		s.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", table))
 	}
@@ -58,7 +63,9 @@ func (s *MysqlSuite) TestOpenMysqlDB() {
 	conf.Pool.MaxIdle = 15
 	conf.Pool.MaxLifeTime = time.Minute
 
-	db, err := openMysqlDB(&conf)
+	db, err := openMysqlDB(&conf, nil, nil)
+	s.NoError(err)
+	err = migrateMysqlDBWithBackfills(context.TODO(), db, &conf, nil)
 
 	s.NoError(err)
 	expectedTables := []string{
@@ -78,6 +85,36 @@ func (s *MysqlSuite) TestOpenMysqlDB() {
 	s.EqualValues(conf.Pool.MaxOpen, stats.MaxOpenConnections)
 }
 
+func (s *MysqlSuite) TestReload() {
+	var conf Config
+	conf.Driver = "mysql"
+	conf.DataSourceName = s.dsn
+	conf.Encryption.ActiveKeyID = "k1"
+	conf.Encryption.Keys = map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))}
+	conf.Encryption.BlindIndexKey = base64.StdEncoding.EncodeToString([]byte("blind-index-key"))
+	db, err := openMysqlDB(&conf, nil, nil)
+	s.NoError(err)
+	sqlDB, err := db.DB()
+	s.NoError(err)
+	defer sqlDB.Close()
+	// opens a new connection for each query.
+	sqlDB.SetMaxIdleConns(0)
+
+	cfg, err := mysqldriver.ParseDSN(s.dsn)
+	s.NoError(err)
+	cfg.Passwd = "rotated"
+	conf.DataSourceName = cfg.FormatDSN()
+	s.NoError(Reload(db, &conf))
+	s.Error(db.Exec("SELECT 1").Error)
+
+	conf.DataSourceName = s.dsn
+	s.NoError(Reload(db, &conf))
+	s.NoError(db.Exec("SELECT 1").Error)
+
+	conf.Replica.DataSourceNames = []string{s.dsn}
+	s.ErrorContains(Reload(db, &conf), "the number of replicas can not be changed")
+}
+
 func (s *MysqlSuite) TestRunInTx() {
 	testRunInTx(s.T(), s.db)
 }
@@ -86,10 +123,30 @@ func (s *MysqlSuite) TestRunInTx_Rollback() {
 	testRunInTxRollback(s.T(), s.db)
 }
 
+func (s *MysqlSuite) TestRunInTx_AfterCommit() {
+	testRunInTxAfterCommit(s.T(), s.db)
+}
+
 func (s *MysqlSuite) TestWrapError() {
 	testWrapError(s.T(), s.db)
 }
 
+func (s *MysqlSuite) TestAuditCallbacks() {
+	testAuditCallbacks(s.T(), s.db)
+}
+
+func (s *MysqlSuite) TestReEncrypt() {
+	testReEncrypt(s.T(), s.db)
+}
+
+func (s *MysqlSuite) TestNewSchemaStatus() {
+	testNewSchemaStatus(s.T(), s.dsn)
+}
+
+func (s *MysqlSuite) TestMigrateMysqlDBWithBackfills() {
+	testMigrateWithBackfills(s.T(), s.dsn, s.db)
+}
+
 func (s *MysqlSuite) TestMigrateMysqlDB() {
 	err := MigrateMysqlDB(s.dsn, "./migrations/mysql", true)
 
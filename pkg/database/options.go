@@ -0,0 +1,26 @@
+package database
+
+// QueryOption configures find queries of stores.
+type QueryOption func(o *QueryOptions)
+
+// QueryOptions represents options of find queries.
+type QueryOptions struct {
+	// WithDeleted includes soft deleted records if true.
+	WithDeleted bool
+}
+
+// WithDeleted includes soft deleted records to find queries.
+func WithDeleted() QueryOption {
+	return func(o *QueryOptions) {
+		o.WithDeleted = true
+	}
+}
+
+// NewQueryOptions returns a new QueryOptions applied given opts.
+func NewQueryOptions(opts ...QueryOption) *QueryOptions {
+	var o QueryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &o
+}
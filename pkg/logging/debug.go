@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var ErrInvalidDebugToken = errors.New("invalid debug token")
+
+// DebugSigner signs and verifies short-lived tokens enabling debug logs of requests of a subject, e.g. a request id.
+// A token is "<expiry in unix seconds>.<base64url encoded hmac-sha256 of the expiry and the subject>",
+// so a leaked token is not valid for other subjects.
+type DebugSigner struct {
+	secret []byte
+	maxTTL time.Duration
+	now    func() time.Time
+}
+
+// NewDebugSigner returns a new DebugSigner signing tokens with given secret.
+// Tokens living longer than maxTTL are not signed or verified.
+func NewDebugSigner(secret string, maxTTL time.Duration) *DebugSigner {
+	return &DebugSigner{secret: []byte(secret), maxTTL: maxTTL, now: time.Now}
+}
+
+// Sign returns a token of given subject expiring after given ttl.
+func (s *DebugSigner) Sign(subject string, ttl time.Duration) (string, error) {
+	if subject == "" {
+		return "", errors.New("require subject")
+	}
+	if ttl <= 0 || ttl > s.maxTTL {
+		return "", fmt.Errorf("ttl must be in (0, %s]: %s", s.maxTTL, ttl)
+	}
+	expiry := strconv.FormatInt(s.now().Add(ttl).Unix(), 10)
+	return expiry + "." + s.signature(expiry, subject), nil
+}
+
+// Verify returns nil if given token is signed by the secret for given subject and not expired,
+// otherwise ErrInvalidDebugToken.
+func (s *DebugSigner) Verify(token, subject string) error {
+	expiry, signature, ok := strings.Cut(token, ".")
+	if !ok || subject == "" || !hmac.Equal([]byte(signature), []byte(s.signature(expiry, subject))) {
+		return fmt.Errorf("%w: invalid signature", ErrInvalidDebugToken)
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid expiry", ErrInvalidDebugToken)
+	}
+	now := s.now()
+	expiresAt := time.Unix(unix, 0)
+	if !now.Before(expiresAt) {
+		return fmt.Errorf("%w: expired at %s", ErrInvalidDebugToken, expiresAt.UTC().Format(time.RFC3339))
+	}
+	// rejects tokens signed with a longer max ttl before.
+	if expiresAt.Sub(now) > s.maxTTL {
+		return fmt.Errorf("%w: expiry exceeds max ttl", ErrInvalidDebugToken)
+	}
+	return nil
+}
+
+func (s *DebugSigner) signature(expiry, subject string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	// the expiry is digits, so the first "." separates the subject.
+	mac.Write([]byte(expiry + "." + subject))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// WithDebug returns a copy of given logger writing entries of all levels including named loggers
+// derived from it regardless of Levels.
+func WithDebug(logger *zap.SugaredLogger) *zap.SugaredLogger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		// sinks are enabled at debug level and filtered by levelCore.
+		if c, ok := core.(*levelCore); ok {
+			return c.Core
+		}
+		return core
+	}))
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestDebugSigner(t *testing.T) {
+	now := time.Now()
+	signer := NewDebugSigner("debug-secret", time.Hour)
+	signer.now = func() time.Time { return now }
+	token, err := signer.Sign("request1", 10*time.Minute)
+	assert.NoError(t, err)
+
+	expired := NewDebugSigner("debug-secret", time.Hour)
+	expired.now = func() time.Time { return now.Add(11 * time.Minute) }
+	shorter := NewDebugSigner("debug-secret", time.Minute)
+	shorter.now = signer.now
+
+	cases := []struct {
+		name    string
+		signer  *DebugSigner
+		token   string
+		subject string
+		valid   bool
+	}{
+		{name: "Valid", signer: signer, token: token, subject: "request1", valid: true},
+		{name: "Expired", signer: expired, token: token, subject: "request1"},
+		{name: "ExceedMaxTTL", signer: shorter, token: token, subject: "request1"},
+		{name: "OtherSecret", signer: NewDebugSigner("other-secret", time.Hour), token: token, subject: "request1"},
+		{name: "OtherSubject", signer: signer, token: token, subject: "request2"},
+		{name: "EmptySubject", signer: signer, token: token},
+		{name: "Tampered", signer: signer, token: "9" + token, subject: "request1"},
+		{name: "Malformed", signer: signer, token: "token", subject: "request1"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.signer.Verify(tc.token, tc.subject)
+
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidDebugToken)
+			}
+		})
+	}
+}
+
+func TestDebugSigner_SignInvalidTTL(t *testing.T) {
+	signer := NewDebugSigner("debug-secret", time.Hour)
+
+	for _, ttl := range []time.Duration{0, 2 * time.Hour} {
+		_, err := signer.Sign("request1", ttl)
+		assert.Error(t, err)
+	}
+	_, err := signer.Sign("", time.Minute)
+	assert.Error(t, err)
+}
+
+func TestWithDebug(t *testing.T) {
+	logger, logs := NewObservedLogger(zapcore.WarnLevel)
+
+	logger.Named("[DB]").Info("ignored")
+	debug := WithDebug(logger).With("requestId", "request1")
+	debug.Debug("root debug")
+	debug.Named("[DB]").Info("db info")
+
+	assert.Equal(t, []string{"root debug", "db info"}, messages(logs))
+	assert.Equal(t, "request1", logs.All()[1].ContextMap()["requestId"])
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// fields is a registry of fields attached to a logger of a context, e.g. a request.
+// Fields added to the registry are attached to loggers of all contexts sharing the registry,
+// so contexts derived before the fields are added are also enriched.
+type fields struct {
+	mu     sync.RWMutex
+	base   *zap.SugaredLogger
+	keys   []string
+	values map[string]interface{}
+	logger *zap.SugaredLogger
+}
+
+// WithFields returns a copy of ctx having a registry of fields attached to given logger.
+// keysAndValues are pairs of string keys and values added to the registry.
+func WithFields(ctx context.Context, logger *zap.SugaredLogger, keysAndValues ...interface{}) context.Context {
+	f := &fields{base: logger, values: make(map[string]interface{}), logger: logger}
+	f.add(keysAndValues)
+	return context.WithValue(ctx, loggerKey, f)
+}
+
+// AddFields adds pairs of string keys and values to the registry of ctx created by WithFields.
+// The value of an existing key is replaced. Does nothing if ctx has no registry.
+func AddFields(ctx context.Context, keysAndValues ...interface{}) {
+	if ctx == nil {
+		return
+	}
+	if f, ok := ctx.Value(loggerKey).(*fields); ok {
+		f.add(keysAndValues)
+	}
+}
+
+func (f *fields) add(keysAndValues []interface{}) {
+	if len(keysAndValues) == 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			continue
+		}
+		if _, exists := f.values[key]; !exists {
+			f.keys = append(f.keys, key)
+		}
+		f.values[key] = keysAndValues[i+1]
+	}
+	args := make([]interface{}, 0, len(f.keys)*2)
+	for _, key := range f.keys {
+		args = append(args, key, f.values[key])
+	}
+	f.logger = f.base.With(args...)
+}
+
+// Logger returns the logger with all fields in the registry.
+func (f *fields) Logger() *zap.SugaredLogger {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.logger
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestFields(t *testing.T) {
+	logger, logs := NewObservedLogger(zapcore.InfoLevel)
+	ctx := WithFields(context.Background(), logger, "requestId", "request1")
+	// derived before the fields are added.
+	derived, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	AddFields(ctx, "userId", 1, "route", "/foo")
+	AddFields(derived, "userId", 2)
+	FromContext(derived).Info("message")
+
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{"requestId": "request1", "userId": int64(2), "route": "/foo"}, entries[0].ContextMap())
+}
+
+func TestFields_WithLogger(t *testing.T) {
+	l1, logs1 := NewObservedLogger(zapcore.InfoLevel)
+	l2, logs2 := NewObservedLogger(zapcore.InfoLevel)
+	ctx := WithLogger(WithFields(context.Background(), l1, "requestId", "request1"), l2)
+
+	AddFields(ctx, "userId", 1)
+	FromContext(ctx).Info("message")
+
+	assert.Equal(t, 0, logs1.Len())
+	assert.Equal(t, 1, logs2.Len())
+	assert.Empty(t, logs2.All()[0].ContextMap())
+}
+
+func TestAddFields_NoRegistry(t *testing.T) {
+	logger, logs := NewObservedLogger(zapcore.InfoLevel)
+	ctx := WithLogger(context.Background(), logger)
+
+	AddFields(ctx, "userId", 1)
+	AddFields(nil, "userId", 1)
+	FromContext(ctx).Info("message")
+
+	assert.Empty(t, logs.All()[0].ContextMap())
+}
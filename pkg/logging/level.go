@@ -0,0 +1,261 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels are levels of a root logger and named loggers which can be changed at runtime.
+// A named logger uses the level of the longest matched name separated by ".", e.g. "cache" for "cache.redis",
+// otherwise uses the root level.
+type Levels struct {
+	root zap.AtomicLevel
+	// min is the most verbose level of root and named which is read without the lock on every log.
+	min zap.AtomicLevel
+
+	mu      sync.RWMutex
+	named   map[string]zapcore.Level
+	reverts map[string]*levelRevert
+}
+
+// levelRevert restores a level set before a temporary level.
+type levelRevert struct {
+	timer *time.Timer
+	level zapcore.Level
+	set   bool
+}
+
+// NewLevels returns a new Levels with given root level.
+func NewLevels(root zapcore.Level) *Levels {
+	return &Levels{
+		root:    zap.NewAtomicLevelAt(root),
+		min:     zap.NewAtomicLevelAt(root),
+		named:   make(map[string]zapcore.Level),
+		reverts: make(map[string]*levelRevert),
+	}
+}
+
+// Enabled returns true if given level is enabled for the logger with given name.
+func (l *Levels) Enabled(name string, level zapcore.Level) bool {
+	return l.Level(name).Enabled(level)
+}
+
+// Level returns an effective level of the logger with given name. The root level is returned if name is empty.
+func (l *Levels) Level(name string) zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if len(l.named) == 0 {
+		return l.root.Level()
+	}
+	for name != "" {
+		if level, ok := l.named[name]; ok {
+			return level
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return l.root.Level()
+}
+
+// MinLevel returns the most verbose level of the root and named loggers.
+func (l *Levels) MinLevel() zapcore.Level {
+	return l.min.Level()
+}
+
+// SetLevel sets the level of the logger with given name or the root logger if name is empty.
+// The previous level is restored after revertAfter if it is positive. A level set temporarily again
+// extends the revert of the first one.
+func (l *Levels) SetLevel(name string, level zapcore.Level, revertAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.scheduleRevert(name, revertAfter)
+	l.set(name, level, true)
+}
+
+// UnsetLevel removes the level of the named logger so that the logger uses the level of its parent.
+func (l *Levels) UnsetLevel(name string, revertAfter time.Duration) {
+	if name == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.scheduleRevert(name, revertAfter)
+	l.set(name, 0, false)
+}
+
+// Named returns levels of all named loggers.
+func (l *Levels) Named() map[string]zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	named := make(map[string]zapcore.Level, len(l.named))
+	for name, level := range l.named {
+		named[name] = level
+	}
+	return named
+}
+
+// scheduleRevert schedules to restore the current level of given name after revertAfter.
+// Must be called with the lock.
+func (l *Levels) scheduleRevert(name string, revertAfter time.Duration) {
+	r, ok := l.reverts[name]
+	if ok {
+		r.timer.Stop()
+		delete(l.reverts, name)
+	}
+	if revertAfter <= 0 {
+		return
+	}
+	if !ok {
+		r = &levelRevert{}
+		r.level, r.set = l.get(name)
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(revertAfter, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		// skip if the revert is replaced.
+		if cur, ok := l.reverts[name]; !ok || cur.timer != timer {
+			return
+		}
+		delete(l.reverts, name)
+		l.set(name, r.level, r.set)
+	})
+	r.timer = timer
+	l.reverts[name] = r
+}
+
+func (l *Levels) get(name string) (zapcore.Level, bool) {
+	if name == "" {
+		return l.root.Level(), true
+	}
+	level, ok := l.named[name]
+	return level, ok
+}
+
+func (l *Levels) set(name string, level zapcore.Level, set bool) {
+	switch {
+	case name == "":
+		l.root.SetLevel(level)
+	case set:
+		l.named[name] = level
+	default:
+		delete(l.named, name)
+	}
+	min := l.root.Level()
+	for _, level := range l.named {
+		if level < min {
+			min = level
+		}
+	}
+	l.min.SetLevel(min)
+}
+
+type levelsResponse struct {
+	Level   string            `json:"level"`
+	Loggers map[string]string `json:"loggers"`
+}
+
+type levelRequest struct {
+	// Logger is a name of the logger. The root logger if empty.
+	Logger string `json:"logger"`
+	// Level is a level to set. The level of the named logger is removed if empty.
+	Level string `json:"level"`
+	// RevertAfter is a duration to restore the previous level, e.g. "10m". Never reverted if empty.
+	RevertAfter string `json:"revert-after"`
+}
+
+// ServeHTTP returns levels on GET and sets a level on PUT with a json body like below.
+// {"logger": "cache", "level": "debug", "revert-after": "10m"}
+func (l *Levels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if err := l.handleSetLevel(r); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "only GET and PUT are supported"})
+		return
+	}
+	res := levelsResponse{
+		Level:   l.Level("").String(),
+		Loggers: make(map[string]string),
+	}
+	for name, level := range l.Named() {
+		res.Loggers[name] = level.String()
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (l *Levels) handleSetLevel(r *http.Request) error {
+	var req levelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	var revertAfter time.Duration
+	if req.RevertAfter != "" {
+		d, err := time.ParseDuration(req.RevertAfter)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid revert-after: %s", req.RevertAfter)
+		}
+		revertAfter = d
+	}
+	if req.Level == "" {
+		if req.Logger == "" {
+			return fmt.Errorf("require level of the root logger")
+		}
+		l.UnsetLevel(req.Logger, revertAfter)
+	} else {
+		level, err := zapcore.ParseLevel(req.Level)
+		if err != nil {
+			return err
+		}
+		l.SetLevel(req.Logger, level, revertAfter)
+	}
+	FromContext(r.Context()).Infow("changed a log level",
+		"logger", req.Logger, "level", req.Level, "revertAfter", revertAfter.String())
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// levelCore filters entries with levels of their logger names.
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+func newLevelCore(core zapcore.Core, levels *Levels) zapcore.Core {
+	return &levelCore{Core: core, levels: levels}
+}
+
+// Enabled returns true if any logger enables given level since names are unknown.
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels.MinLevel().Enabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.Enabled(ent.LoggerName, ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
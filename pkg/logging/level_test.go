@@ -0,0 +1,168 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevels(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	levels.SetLevel("cache", zapcore.DebugLevel, 0)
+	levels.SetLevel("[DB]", zapcore.WarnLevel, 0)
+
+	assert.Equal(t, zapcore.InfoLevel, levels.Level(""))
+	assert.Equal(t, zapcore.InfoLevel, levels.Level("fx"))
+	assert.Equal(t, zapcore.DebugLevel, levels.Level("cache"))
+	assert.Equal(t, zapcore.DebugLevel, levels.Level("cache.redis"))
+	assert.Equal(t, zapcore.InfoLevel, levels.Level("cached"))
+	assert.Equal(t, zapcore.WarnLevel, levels.Level("[DB]"))
+	assert.Equal(t, zapcore.DebugLevel, levels.MinLevel())
+
+	levels.UnsetLevel("cache", 0)
+	assert.Equal(t, zapcore.InfoLevel, levels.Level("cache.redis"))
+	assert.Equal(t, map[string]zapcore.Level{"[DB]": zapcore.WarnLevel}, levels.Named())
+	assert.Equal(t, zapcore.InfoLevel, levels.MinLevel())
+	levels.SetLevel("", zapcore.ErrorLevel, 0)
+	assert.Equal(t, zapcore.WarnLevel, levels.MinLevel())
+}
+
+func TestLevels_Revert(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+
+	levels.SetLevel("", zapcore.DebugLevel, 50*time.Millisecond)
+	levels.SetLevel("fx", zapcore.DebugLevel, 50*time.Millisecond)
+	// extends the revert of the first one.
+	levels.SetLevel("fx", zapcore.ErrorLevel, 100*time.Millisecond)
+
+	assert.Equal(t, zapcore.DebugLevel, levels.Level(""))
+	assert.Equal(t, zapcore.ErrorLevel, levels.Level("fx"))
+	assert.Eventually(t, func() bool {
+		return levels.Level("") == zapcore.InfoLevel
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, zapcore.ErrorLevel, levels.Level("fx"))
+	assert.Eventually(t, func() bool {
+		_, ok := levels.Named()["fx"]
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+
+	// a permanent level cancels the revert.
+	levels.SetLevel("", zapcore.DebugLevel, 50*time.Millisecond)
+	levels.SetLevel("", zapcore.WarnLevel, 0)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, zapcore.WarnLevel, levels.Level(""))
+}
+
+func TestLevelCore(t *testing.T) {
+	var (
+		buf    bytes.Buffer
+		levels = NewLevels(zapcore.InfoLevel)
+		core   = zapcore.NewCore(zapcore.NewJSONEncoder(NewEncoderConfig()), zapcore.AddSync(&buf), zapcore.DebugLevel)
+		logger = zap.New(newLevelCore(core, levels))
+	)
+	levels.SetLevel("cache", zapcore.DebugLevel, 0)
+	levels.SetLevel("[DB]", zapcore.ErrorLevel, 0)
+
+	logger.Debug("root debug")
+	logger.Info("root info")
+	logger.Named("cache").With(zap.String("k", "v")).Debug("cache debug")
+	logger.Named("[DB]").Warn("db warn")
+
+	output := buf.String()
+	assert.NotContains(t, output, "root debug")
+	assert.Contains(t, output, "root info")
+	assert.Contains(t, output, "cache debug")
+	assert.NotContains(t, output, "db warn")
+}
+
+func TestLevels_ServeHTTP(t *testing.T) {
+	levels := NewLevels(zapcore.InfoLevel)
+	cases := []struct {
+		name     string
+		method   string
+		body     string
+		code     int
+		level    string
+		loggers  map[string]string
+		contains string
+	}{
+		{
+			name:    "Get",
+			method:  http.MethodGet,
+			code:    http.StatusOK,
+			level:   "info",
+			loggers: map[string]string{},
+		}, {
+			name:    "Set Named",
+			method:  http.MethodPut,
+			body:    `{"logger": "cache", "level": "debug", "revert-after": "10m"}`,
+			code:    http.StatusOK,
+			level:   "info",
+			loggers: map[string]string{"cache": "debug"},
+		}, {
+			name:    "Set Root",
+			method:  http.MethodPut,
+			body:    `{"level": "warn"}`,
+			code:    http.StatusOK,
+			level:   "warn",
+			loggers: map[string]string{"cache": "debug"},
+		}, {
+			name:    "Unset Named",
+			method:  http.MethodPut,
+			body:    `{"logger": "cache"}`,
+			code:    http.StatusOK,
+			level:   "warn",
+			loggers: map[string]string{},
+		}, {
+			name:     "Invalid Level",
+			method:   http.MethodPut,
+			body:     `{"level": "verbose"}`,
+			code:     http.StatusBadRequest,
+			contains: "unrecognized level",
+		}, {
+			name:     "Invalid Revert",
+			method:   http.MethodPut,
+			body:     `{"level": "debug", "revert-after": "-1m"}`,
+			code:     http.StatusBadRequest,
+			contains: "invalid revert-after",
+		}, {
+			name:     "Unset Root",
+			method:   http.MethodPut,
+			body:     `{}`,
+			code:     http.StatusBadRequest,
+			contains: "require level",
+		}, {
+			name:     "Not Allowed",
+			method:   http.MethodPost,
+			code:     http.StatusMethodNotAllowed,
+			contains: "only GET and PUT",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tc.method, "/admin/log/level", strings.NewReader(tc.body))
+
+			levels.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+			if tc.contains != "" {
+				assert.Contains(t, rec.Body.String(), tc.contains)
+				return
+			}
+			var res levelsResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, tc.level, res.Level)
+			assert.Equal(t, tc.loggers, res.Loggers)
+		})
+	}
+}
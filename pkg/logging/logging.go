@@ -2,7 +2,9 @@ package logging
 
 import (
 	"context"
-	"sync"
+	"fmt"
+	"os"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -12,63 +14,107 @@ type contextKey int
 
 const loggerKey contextKey = iota
 
-var (
-	defaultLogger     *zap.SugaredLogger
-	defaultLoggerOnce sync.Once
-)
-
-var conf = &Config{
-	Encoding:          "console",
-	Level:             zapcore.InfoLevel,
-	Development:       true,
-	EncoderConfig:     NewEncoderConfig(),
-	DisableStacktrace: true,
+// defaults are the default logger and its levels used if a context has no logger.
+type defaults struct {
+	logger *zap.SugaredLogger
+	levels *Levels
 }
 
+var defaultValue atomic.Pointer[defaults]
+
 type Config struct {
 	Encoding          string
 	Level             zapcore.Level
 	Development       bool
 	EncoderConfig     zapcore.EncoderConfig
 	DisableStacktrace bool
+	// Sinks are outputs of logs. Writes to stdout if empty.
+	Sinks    []SinkConfig
+	Sampling SamplingConfig
+	// ErrorOutputPaths are outputs of internal errors of loggers. Writes to stderr if empty.
+	ErrorOutputPaths []string
 }
 
-// SetConfig sets given logging configs for DefaultLogger's logger.
-// Must set configs before calling DefaultLogger()
-func SetConfig(c *Config) {
-	conf = &Config{
-		Encoding:          c.Encoding,
-		Level:             c.Level,
-		Development:       c.Development,
-		EncoderConfig:     c.EncoderConfig,
-		DisableStacktrace: c.DisableStacktrace,
+// NewDefaultConfig returns a config of the default logger used until SetDefault is called.
+func NewDefaultConfig() *Config {
+	return &Config{
+		Encoding:          "console",
+		Level:             zapcore.InfoLevel,
+		Development:       true,
+		EncoderConfig:     NewEncoderConfig(),
+		DisableStacktrace: true,
 	}
 }
 
-// NewLogger creates a new logger with the config.Context i.e config package should be initialized
-func NewLogger() *zap.SugaredLogger {
-	conf := zap.Config{
-		Encoding:          conf.Encoding,
-		EncoderConfig:     conf.EncoderConfig,
-		Level:             zap.NewAtomicLevelAt(conf.Level),
-		Development:       conf.Development,
-		OutputPaths:       []string{"stdout"},
-		ErrorOutputPaths:  []string{"stderr"},
-		DisableStacktrace: conf.DisableStacktrace,
+// NewLogger creates a new logger and its levels with given config.
+// Secrets in messages and fields are masked by maskingutil.
+func NewLogger(c *Config) (*zap.Logger, *Levels, error) {
+	levels := NewLevels(c.Level)
+	logger, err := build(c, levels)
+	if err != nil {
+		return nil, nil, err
 	}
-	logger, err := conf.Build()
+	return logger, levels, nil
+}
+
+func build(c *Config, levels *Levels) (*zap.Logger, error) {
+	core, err := newCore(c)
 	if err != nil {
-		logger = zap.NewNop()
+		return nil, err
+	}
+	errorOutputPaths := c.ErrorOutputPaths
+	if len(errorOutputPaths) == 0 {
+		errorOutputPaths = []string{"stderr"}
+	}
+	errSink, _, err := zap.Open(errorOutputPaths...)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []zap.Option{zap.ErrorOutput(errSink), zap.AddCaller()}
+	stackLevel := zapcore.ErrorLevel
+	if c.Development {
+		opts = append(opts, zap.Development())
+		stackLevel = zapcore.WarnLevel
+	}
+	if !c.DisableStacktrace {
+		opts = append(opts, zap.AddStacktrace(stackLevel))
 	}
-	return logger.Sugar()
+	return zap.New(newLevelCore(core, levels), opts...), nil
+}
+
+// SetDefault replaces the default logger and levels returned by DefaultLogger and DefaultLevels.
+func SetDefault(logger *zap.SugaredLogger, levels *Levels) {
+	defaultValue.Store(&defaults{logger: logger, levels: levels})
 }
 
 // DefaultLogger returns the default logger for the package.
+// A logger built with NewDefaultConfig is returned until SetDefault is called.
 func DefaultLogger() *zap.SugaredLogger {
-	defaultLoggerOnce.Do(func() {
-		defaultLogger = NewLogger()
-	})
-	return defaultLogger
+	return loadDefaults().logger
+}
+
+// DefaultLevels returns the levels of DefaultLogger and loggers derived from it.
+func DefaultLevels() *Levels {
+	return loadDefaults().levels
+}
+
+func loadDefaults() *defaults {
+	if d := defaultValue.Load(); d != nil {
+		return d
+	}
+	d := defaults{levels: NewLevels(zapcore.InfoLevel)}
+	logger, err := build(NewDefaultConfig(), d.levels)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build a logger: %v\n", err)
+		logger = zap.NewNop()
+	}
+	d.logger = logger.Sugar()
+	// keeps the default set by others first.
+	if !defaultValue.CompareAndSwap(nil, &d) {
+		return defaultValue.Load()
+	}
+	return &d
 }
 
 // WithLogger creates a new context with the provided logger attached.
@@ -78,10 +124,21 @@ func WithLogger(ctx context.Context, logger *zap.SugaredLogger) context.Context
 
 // FromContext returns the logger stored in the context, otherwise a default logger is returned.
 func FromContext(ctx context.Context) *zap.SugaredLogger {
-	if ctx == nil {
-		return DefaultLogger()
+	return FromContextOr(ctx, nil)
+}
+
+// FromContextOr returns the logger stored in the context with fields added by AddFields,
+// otherwise given logger or a default logger if nil.
+func FromContextOr(ctx context.Context, logger *zap.SugaredLogger) *zap.SugaredLogger {
+	if ctx != nil {
+		switch l := ctx.Value(loggerKey).(type) {
+		case *zap.SugaredLogger:
+			return l
+		case *fields:
+			return l.Logger()
+		}
 	}
-	if logger, ok := ctx.Value(loggerKey).(*zap.SugaredLogger); ok {
+	if logger != nil {
 		return logger
 	}
 	return DefaultLogger()
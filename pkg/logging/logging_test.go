@@ -9,11 +9,19 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestNewLogger(t *testing.T) {
-	assert.NotNil(t, NewLogger())
+	logger, levels, err := NewLogger(NewDefaultConfig())
+
+	assert.NoError(t, err)
+	assert.NotNil(t, logger)
+	assert.Equal(t, zapcore.InfoLevel, levels.Level(""))
 }
 
 func TestDefaultLogger(t *testing.T) {
@@ -26,6 +34,64 @@ func TestDefaultLogger(t *testing.T) {
 	assert.Equal(t, l1, l2)
 }
 
+func TestSetDefault(t *testing.T) {
+	prev := loadDefaults()
+	defer SetDefault(prev.logger, prev.levels)
+	logger, logs := NewObservedLogger(zapcore.InfoLevel)
+	levels := NewLevels(zapcore.WarnLevel)
+
+	SetDefault(logger, levels)
+	FromContext(context.Background()).Infow("default message", "k", "v")
+
+	assert.Equal(t, levels, DefaultLevels())
+	assert.Equal(t, 1, logs.FilterMessage("default message").FilterField(zap.String("k", "v")).Len())
+}
+
+func TestModule(t *testing.T) {
+	prev := DefaultLogger()
+	var logger *zap.SugaredLogger
+
+	app := fxtest.New(t, fx.Supply(NewDefaultConfig()), Module, fx.Populate(&logger))
+	app.RequireStart().RequireStop()
+
+	assert.NotNil(t, logger)
+	// the provided logger is injected only.
+	assert.Same(t, prev, DefaultLogger())
+}
+
+func TestFromContextOr(t *testing.T) {
+	l1, logs1 := NewObservedLogger(zapcore.InfoLevel)
+	l2, logs2 := NewObservedLogger(zapcore.InfoLevel)
+
+	FromContextOr(context.Background(), l1).Info("fallback")
+	FromContextOr(WithLogger(context.Background(), l2), l1).Info("context")
+
+	assert.Equal(t, []string{"fallback"}, messages(logs1))
+	assert.Equal(t, []string{"context"}, messages(logs2))
+	assert.Equal(t, DefaultLogger(), FromContextOr(nil, nil))
+}
+
+func TestNewObservedLogger(t *testing.T) {
+	logger, logs := NewObservedLogger(zapcore.InfoLevel)
+
+	logger.Debugw("debug message")
+	logger.Named("cache").Warnw("warn message", "key", "key1")
+
+	entries := logs.All()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "cache", entries[0].LoggerName)
+	assert.Equal(t, "warn message", entries[0].Message)
+	assert.Equal(t, map[string]interface{}{"key": "key1"}, entries[0].ContextMap())
+}
+
+func messages(logs *observer.ObservedLogs) []string {
+	var messages []string
+	for _, e := range logs.All() {
+		messages = append(messages, e.Message)
+	}
+	return messages
+}
+
 func TestFromContext(t *testing.T) {
 	cases := []struct {
 		name string
@@ -59,9 +125,7 @@ func TestFromContextWithNil(t *testing.T) {
 }
 
 func TestLoggingFormat(t *testing.T) {
-	SetConfig(&Config{Encoding: "json", Level: -1})
-
-	output := captureLoggingOutput(func() {
+	output := captureLoggingOutput(&Config{Encoding: "json", Level: -1}, func() {
 		DefaultLogger().Errorw("my log message", "x-request-id", "request1")
 	})
 	t.Log(output)
@@ -79,29 +143,21 @@ func TestLoggingFormat(t *testing.T) {
 	assert.Equal(t, "request1", res["x-request-id"].(string))
 }
 
-func captureLoggingOutput(doFunc func()) string {
-	DefaultLogger() // call to initialize default logger if not exist
-	oldLogger := defaultLogger
+func captureLoggingOutput(c *Config, doFunc func()) string {
+	prev := loadDefaults()
 	sink := &MemorySink{new(bytes.Buffer)}
 	zap.RegisterSink("memory", func(*url.URL) (zap.Sink, error) {
 		return sink, nil
 	})
 
-	cfg := zap.Config{
-		Encoding:         conf.Encoding,
-		EncoderConfig:    NewEncoderConfig(),
-		Level:            zap.NewAtomicLevelAt(conf.Level),
-		Development:      conf.Development,
-		OutputPaths:      []string{"memory://"},
-		ErrorOutputPaths: []string{"memory://"},
-	}
-
-	newLogger, _ := cfg.Build()
-	defaultLogger = newLogger.Sugar()
+	c.EncoderConfig = NewEncoderConfig()
+	c.Sinks = []SinkConfig{{Path: "memory://"}}
+	logger, levels, _ := NewLogger(c)
+	SetDefault(logger.Sugar(), levels)
 
 	doFunc()
 
-	defaultLogger = oldLogger
+	SetDefault(prev.logger, prev.levels)
 	return sink.String()
 }
 
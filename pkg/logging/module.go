@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Module provides *zap.Logger, *zap.SugaredLogger and *Levels built from a supplied *Config.
+// The logger is synced on stop. It's not set as the default logger, so components must be given it.
+var Module = fx.Module("logging",
+	fx.Provide(
+		newModuleLogger,
+		func(logger *zap.Logger) *zap.SugaredLogger {
+			return logger.Sugar()
+		},
+	),
+)
+
+func newModuleLogger(lc fx.Lifecycle, c *Config) (*zap.Logger, *Levels, error) {
+	logger, levels, err := NewLogger(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			// ignores errors of syncing stdout and stderr not supported on some platforms.
+			_ = logger.Sync()
+			return nil
+		},
+	})
+	return logger, levels, nil
+}
@@ -0,0 +1,19 @@
+package logging
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// NewObservedLogger returns a logger recording entries enabled by given level for tests
+// to assert log outputs by returned logs.
+func NewObservedLogger(level zapcore.Level) (*zap.SugaredLogger, *observer.ObservedLogs) {
+	return NewObservedLoggerWithLevels(NewLevels(level))
+}
+
+// NewObservedLoggerWithLevels returns a logger like NewObservedLogger filtering entries by given levels.
+func NewObservedLoggerWithLevels(levels *Levels) (*zap.SugaredLogger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(newLevelCore(core, levels)).Sugar(), logs
+}
@@ -0,0 +1,173 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/zacscoding/go-rest-template/pkg/utils/maskingutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// SinkConfig is an output of logs.
+type SinkConfig struct {
+	// Path is "stdout", "stderr", a file path or an url of a sink registered by zap.RegisterSink.
+	Path string `json:"path" yaml:"path"`
+	// Encoding is json or console. The encoding of the logger is used if empty.
+	Encoding string `json:"encoding" yaml:"encoding"`
+	// Rotation rotates the file of Path.
+	Rotation RotationConfig `json:"rotation" yaml:"rotation"`
+}
+
+// RotationConfig rotates a log file by size and interval and retains rotated files by count and age.
+type RotationConfig struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	// MaxSize is a max size in megabytes of a file before rotated. 100 megabytes if zero.
+	MaxSize int `json:"max-size" yaml:"max-size"`
+	// Interval rotates a file every interval aligned to the unix epoch, e.g. "24h" at midnight UTC. Disabled if zero.
+	Interval time.Duration `json:"interval" yaml:"interval"`
+	// MaxAge is a max age of rotated files rounded up to days. Not removed by age if zero.
+	MaxAge time.Duration `json:"max-age" yaml:"max-age"`
+	// MaxBackups is a max number of rotated files. Not removed by count if zero.
+	MaxBackups int  `json:"max-backups" yaml:"max-backups"`
+	Compress   bool `json:"compress" yaml:"compress"`
+}
+
+// SamplingConfig limits logs having the same level and message to Initial entries and then
+// every Thereafter entries each Tick.
+type SamplingConfig struct {
+	Enabled    bool          `json:"enabled" yaml:"enabled"`
+	Tick       time.Duration `json:"tick" yaml:"tick"`
+	Initial    int           `json:"initial" yaml:"initial"`
+	Thereafter int           `json:"thereafter" yaml:"thereafter"`
+}
+
+func (c *SinkConfig) Validate() error {
+	var result *multierror.Error
+	if c.Path == "" {
+		result = multierror.Append(result, errors.New("require path"))
+	}
+	if c.Encoding != "" && c.Encoding != "json" && c.Encoding != "console" {
+		result = multierror.Append(result, fmt.Errorf("encoding must be json or console: %q", c.Encoding))
+	}
+	if c.Rotation.Enabled {
+		if c.Path == "stdout" || c.Path == "stderr" {
+			result = multierror.Append(result, fmt.Errorf("rotation is not supported on %s", c.Path))
+		}
+		if c.Rotation.MaxSize < 0 || c.Rotation.MaxBackups < 0 || c.Rotation.Interval < 0 || c.Rotation.MaxAge < 0 {
+			result = multierror.Append(result, errors.New("rotation max-size, max-backups, interval and max-age must not be negative"))
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+func (c *SamplingConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Tick <= 0 || c.Initial <= 0 || c.Thereafter < 0 {
+		return errors.New("sampling tick and initial must be positive and thereafter must not be negative")
+	}
+	return nil
+}
+
+// newCore returns a core writing to all sinks of given conf.
+func newCore(c *Config) (zapcore.Core, error) {
+	sinks := c.Sinks
+	if len(sinks) == 0 {
+		sinks = []SinkConfig{{Path: "stdout"}}
+	}
+	cores := make([]zapcore.Core, 0, len(sinks))
+	for _, sink := range sinks {
+		encoding := sink.Encoding
+		if encoding == "" {
+			encoding = c.Encoding
+		}
+		enc, err := newEncoder(encoding, c.EncoderConfig)
+		if err != nil {
+			return nil, err
+		}
+		ws, err := openSink(&sink)
+		if err != nil {
+			return nil, err
+		}
+		// entries are filtered by levels.
+		cores = append(cores, zapcore.NewCore(enc, ws, zapcore.DebugLevel))
+	}
+	core := zapcore.NewTee(cores...)
+	if c.Sampling.Enabled {
+		core = zapcore.NewSamplerWithOptions(core, c.Sampling.Tick, c.Sampling.Initial, c.Sampling.Thereafter)
+	}
+	return core, nil
+}
+
+// safeFieldKeys are keys of request fields added to loggers of every request which never have secrets.
+var safeFieldKeys = []string{"requestId", "traceId", "route", "clientIP"}
+
+// newEncoder returns an encoder masking secrets by maskingutil.
+func newEncoder(encoding string, encoderConfig zapcore.EncoderConfig) (zapcore.Encoder, error) {
+	switch encoding {
+	case "json":
+		return maskingutil.NewEncoder(zapcore.NewJSONEncoder(encoderConfig), safeFieldKeys...), nil
+	case "console":
+		return maskingutil.NewEncoder(zapcore.NewConsoleEncoder(encoderConfig), safeFieldKeys...), nil
+	}
+	return nil, fmt.Errorf("not supported encoding: %q", encoding)
+}
+
+func openSink(c *SinkConfig) (zapcore.WriteSyncer, error) {
+	if !c.Rotation.Enabled {
+		ws, _, err := zap.Open(c.Path)
+		return ws, err
+	}
+	return zapcore.AddSync(&rotatingWriter{
+		Logger: &lumberjack.Logger{
+			Filename:   c.Path,
+			MaxSize:    c.Rotation.MaxSize,
+			MaxAge:     int((c.Rotation.MaxAge + 24*time.Hour - 1) / (24 * time.Hour)),
+			MaxBackups: c.Rotation.MaxBackups,
+			Compress:   c.Rotation.Compress,
+		},
+		interval: c.Rotation.Interval,
+		now:      time.Now,
+	}), nil
+}
+
+// rotatingWriter rotates a file every interval in addition to rotations by size.
+type rotatingWriter struct {
+	*lumberjack.Logger
+	interval time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	if w.interval > 0 {
+		if err := w.rotateIfDue(); err != nil {
+			return 0, err
+		}
+	}
+	return w.Logger.Write(p)
+}
+
+func (w *rotatingWriter) rotateIfDue() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.now()
+	if now.Before(w.next) {
+		return nil
+	}
+	// the first write does not rotate a file written before.
+	rotate := !w.next.IsZero()
+	w.next = now.Truncate(w.interval).Add(w.interval)
+	if rotate {
+		return w.Logger.Rotate()
+	}
+	return nil
+}
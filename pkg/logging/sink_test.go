@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func TestBuild_Sinks(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath, consolePath := filepath.Join(dir, "app.json.log"), filepath.Join(dir, "app.log")
+	logger, err := build(&Config{
+		Encoding:          "console",
+		EncoderConfig:     NewEncoderConfig(),
+		DisableStacktrace: true,
+		Sinks: []SinkConfig{
+			{Path: jsonPath, Encoding: "json", Rotation: RotationConfig{Enabled: true, MaxSize: 1}},
+			{Path: consolePath},
+		},
+		Sampling: SamplingConfig{Enabled: true, Tick: time.Minute, Initial: 2, Thereafter: 0},
+	}, NewLevels(zapcore.InfoLevel))
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		logger.Error("hot error loop", zap.Int("i", i))
+	}
+	logger.Info("other message with password=pass1")
+	assert.NoError(t, logger.Sync())
+
+	b, err := os.ReadFile(jsonPath)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	// sampled to the initial entries.
+	assert.Len(t, lines, 3)
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[2]), &m))
+	assert.Equal(t, "other message with password=****", m["M"])
+
+	b, err = os.ReadFile(consolePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(b), "\n"))
+	assert.Contains(t, string(b), "\tINFO\t")
+}
+
+func TestBuild_Invalid(t *testing.T) {
+	_, err := build(&Config{Encoding: "text", EncoderConfig: NewEncoderConfig()}, NewLevels(zapcore.InfoLevel))
+	assert.ErrorContains(t, err, "not supported encoding")
+
+	_, err = build(&Config{
+		Encoding:      "json",
+		EncoderConfig: NewEncoderConfig(),
+		Sinks:         []SinkConfig{{Path: "unknown://sink"}},
+	}, NewLevels(zapcore.InfoLevel))
+	assert.Error(t, err)
+}
+
+func TestRotatingWriter_Interval(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		now = time.Date(2023, 1, 1, 23, 0, 0, 0, time.UTC)
+		w   = rotatingWriter{
+			Logger:   &lumberjack.Logger{Filename: filepath.Join(dir, "app.log")},
+			interval: 24 * time.Hour,
+			now:      func() time.Time { return now },
+		}
+	)
+	defer w.Close()
+
+	_, err := w.Write([]byte("day1\n"))
+	assert.NoError(t, err)
+	now = now.Add(30 * time.Minute)
+	_, err = w.Write([]byte("day1\n"))
+	assert.NoError(t, err)
+	now = now.Add(time.Hour)
+	_, err = w.Write([]byte("day2\n"))
+	assert.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+	b, err := os.ReadFile(filepath.Join(dir, "app.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "day2\n", string(b))
+}
+
+func TestSinkConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&SinkConfig{Path: "app.log", Rotation: RotationConfig{Enabled: true}}).Validate())
+	err := (&SinkConfig{Encoding: "text", Rotation: RotationConfig{Enabled: true, MaxSize: -1}}).Validate()
+	for _, msg := range []string{"require path", "encoding must be json or console", "must not be negative"} {
+		assert.ErrorContains(t, err, msg)
+	}
+	assert.ErrorContains(t, (&SinkConfig{Path: "stderr", Rotation: RotationConfig{Enabled: true}}).Validate(),
+		"rotation is not supported on stderr")
+}
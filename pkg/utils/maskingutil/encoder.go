@@ -0,0 +1,87 @@
+package maskingutil
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewEncoder returns a zapcore.Encoder that masks secrets of messages and fields by MaskString and MaskValue
+// before delegating to given encoder. String fields of given safeKeys are not masked, e.g. request ids
+// never having secrets, to avoid scanning them on every entry.
+func NewEncoder(delegate zapcore.Encoder, safeKeys ...string) zapcore.Encoder {
+	safe := make(map[string]struct{}, len(safeKeys))
+	for _, key := range safeKeys {
+		safe[key] = struct{}{}
+	}
+	return &maskingEncoder{Encoder: delegate, safeKeys: safe}
+}
+
+type maskingEncoder struct {
+	zapcore.Encoder
+	safeKeys map[string]struct{}
+}
+
+func (e *maskingEncoder) Clone() zapcore.Encoder {
+	return &maskingEncoder{Encoder: e.Encoder.Clone(), safeKeys: e.safeKeys}
+}
+
+func (e *maskingEncoder) AddString(key, value string) {
+	if _, ok := e.safeKeys[key]; ok {
+		e.Encoder.AddString(key, value)
+		return
+	}
+	e.Encoder.AddString(key, MaskString(value))
+}
+
+func (e *maskingEncoder) AddByteString(key string, value []byte) {
+	e.Encoder.AddString(key, MaskString(string(value)))
+}
+
+func (e *maskingEncoder) AddReflected(key string, value interface{}) error {
+	return e.Encoder.AddReflected(key, MaskValue(value))
+}
+
+func (e *maskingEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	ent.Message = MaskString(ent.Message)
+	masked := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		if _, ok := e.safeKeys[f.Key]; ok && f.Type == zapcore.StringType {
+			masked[i] = f
+			continue
+		}
+		masked[i] = MaskField(f)
+	}
+	return e.Encoder.EncodeEntry(ent, masked)
+}
+
+// MaskField returns a field having secrets of given field masked.
+func MaskField(f zapcore.Field) zapcore.Field {
+	switch f.Type {
+	case zapcore.StringType:
+		f.String = MaskString(f.String)
+	case zapcore.ByteStringType:
+		return zap.String(f.Key, MaskString(string(f.Interface.([]byte))))
+	case zapcore.ReflectType:
+		f.Interface = MaskValue(f.Interface)
+	case zapcore.StringerType:
+		return zap.String(f.Key, MaskString(stringify(f.Interface)))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok && err != nil {
+			return zap.String(f.Key, MaskString(err.Error()))
+		}
+	}
+	return f
+}
+
+func stringify(v interface{}) (s string) {
+	// follows zap which encodes a panic of nil stringers.
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("PANIC=%v", r)
+		}
+	}()
+	return v.(fmt.Stringer).String()
+}
@@ -0,0 +1,41 @@
+package maskingutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := NewEncoder(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), "requestId")
+	logger := zap.New(zapcore.NewCore(encoder, zapcore.AddSync(&buf), zapcore.DebugLevel)).
+		With(zap.String("dsn", "root:pass1@tcp(127.0.0.1:3306)/mydb"))
+
+	logger.Info("login with password=pass2",
+		zap.String("header", "Bearer token3"),
+		zap.ByteString("body", []byte(`{"password":"pass4"}`)),
+		zap.Any("conf", testConfig{Password: "pass5"}),
+		zap.Error(errors.New("invalid api_key=key6")),
+		zap.Int("count", 1),
+		zap.String("requestId", "token=req7"),
+	)
+
+	var m map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &m))
+	assert.NotRegexp(t, `pass\d|token3|key6`, buf.String())
+	assert.Equal(t, "login with password=****", m["msg"])
+	assert.Equal(t, "root:****@tcp(127.0.0.1:3306)/mydb", m["dsn"])
+	assert.Equal(t, "Bearer ****", m["header"])
+	assert.Equal(t, `{"password":"****"}`, m["body"])
+	assert.Equal(t, "****", m["conf"].(map[string]interface{})["password"])
+	assert.Equal(t, "invalid api_key=****", m["error"])
+	assert.EqualValues(t, 1, m["count"])
+	// safe keys are not masked.
+	assert.Equal(t, "token=req7", m["requestId"])
+}
@@ -0,0 +1,185 @@
+package maskingutil
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces secret values.
+const Mask = "****"
+
+// secretRegex matches secrets in strings by alternatives below, so strings are scanned once.
+// Each alternative starts with a group kept in front of the mask.
+var secretRegex = regexp.MustCompile(`(?i)` + strings.Join([]string{
+	// authorization headers.
+	`(\b(?:bearer|basic)\s+)[A-Za-z0-9\-._~+/]+=*`,
+	// JSON web tokens.
+	`()\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`,
+	// AWS access key ids.
+	`()\b(?-i:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	// key-value pairs of query strings, json and logfmt. Keys are matched by whole names or their last segment,
+	// e.g. "password" and "access_token" but not "max_tokens".
+	`((?:^|[^\w.-])(?:[\w.-]*[_.-])?(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key)` +
+		`["']?\s*[:=]\s*["']?)[^\s"'&,;]+`,
+}, "|"))
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// MaskString masks a password of urls and common token and key patterns in given value.
+func MaskString(value string) string {
+	value = MaskPassword(value)
+	matches := secretRegex.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 {
+		return value
+	}
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, m := range matches {
+		// keeps the group of the matched alternative.
+		kept := m[0]
+		for i := 2; i < len(m); i += 2 {
+			if m[i] >= 0 {
+				kept = m[i+1]
+				break
+			}
+		}
+		b.WriteString(value[last:kept])
+		b.WriteString(Mask)
+		last = m[1]
+	}
+	b.WriteString(value[last:])
+	return b.String()
+}
+
+// MaskValue returns a copy of given value to be encoded as json with secrets masked.
+// Fields of structs having `secret:"true"` tag are masked if not empty and all strings are masked by MaskString.
+// Structs are converted to maps keyed by names of json tags and values implementing
+// json.Marshaler or encoding.TextMarshaler are kept as it is.
+func MaskValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return maskValue(reflect.ValueOf(v), false)
+}
+
+func maskValue(v reflect.Value, secret bool) interface{} {
+	if secret {
+		if v.IsZero() {
+			return v.Interface()
+		}
+		return maskSecret(v)
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Pointer && isMarshaler(v.Type()) {
+			return v.Interface()
+		}
+		return maskValue(v.Elem(), false)
+	case reflect.String:
+		return MaskString(v.String())
+	case reflect.Struct:
+		if isMarshaler(v.Type()) {
+			return v.Interface()
+		}
+		m := make(map[string]interface{})
+		maskFields(v, m)
+		return m
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = maskValue(iter.Value(), false)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = maskValue(v.Index(i), false)
+		}
+		return s
+	}
+	return v.Interface()
+}
+
+// maskFields puts masked fields of given struct to m with names of json tags.
+func maskFields(v reflect.Value, m map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		// fields of embedded structs are promoted like encoding/json.
+		if field.Anonymous && name == "" {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && !isMarshaler(fv.Type()) {
+				maskFields(fv, m)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		m[name] = maskValue(fv, field.Tag.Get("secret") == "true")
+	}
+}
+
+// maskSecret masks all values of a secret field while keeping its shape.
+func maskSecret(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			m := make(map[string]interface{}, v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				m[iter.Key().String()] = Mask
+			}
+			return m
+		}
+	case reflect.Slice, reflect.Array:
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = Mask
+		}
+		return s
+	}
+	return Mask
+}
+
+func isMarshaler(t reflect.Type) bool {
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+	if t.Kind() != reflect.Pointer {
+		pt := reflect.PointerTo(t)
+		return pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType)
+	}
+	return false
+}